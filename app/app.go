@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	guuid "github.com/google/uuid"
@@ -14,30 +15,60 @@ const pageSize = 6
 func main() {
 	fmt.Println("Program is starting")
 
+	accountClient, err := client.NewClient(host)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	var accountID, organisationID string
 	accountID = guuid.New().String()
 	organisationID = guuid.New().String()
 
+	ctx := context.Background()
+
 	// create the account
-	account := client.CreateRequestBody(accountID, organisationID)
-	createAccountResponse, _ := client.CreateAccount(host, account)
-	createdAccount, _ := client.UnmarshallCreateAccountResponse(createAccountResponse)
-	fmt.Printf("Created Account with AccountId %s", createdAccount.Cdata.ID)
+	account, err := client.NewAccount("GB").
+		WithID(accountID).
+		WithOrganisationID(organisationID).
+		WithBankID("400300").
+		WithBic("NWBKGB22").
+		WithNames("Samantha Holder").
+		WithAlternativeNames("Sam Holder").
+		WithAccountClassification("Personal").
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	createdAccount, err := accountClient.Create(ctx, account)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Created Account with AccountId %s\n", createdAccount.ID)
 
 	// fetch the account
-	getAccountResponse, _ := client.GetAccount(host, accountID)
-	existingAccount, _ := client.UnmarshallGetAccountResponse(getAccountResponse)
-	fmt.Printf("Get Existing Account with AccountId %s", existingAccount.Gdata.ID)
+	existingAccount, err := accountClient.Fetch(ctx, accountID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Get Existing Account with AccountId %s\n", existingAccount.ID)
 
 	// get all existing accounts in db and print them out
-	accounts := client.GatherAccounts(host, pageSize)
+	accounts := accountClient.GatherAccounts(pageSize)
 	fmt.Printf("No of accouns in db:%d\n", len(accounts))
 	for _, d := range accounts {
 		fmt.Println(d.Type, d.ID, d.OrganisationID, d.Version, d.Attributes.Country, d.Attributes.BaseCurrency)
 	}
 
-	// delete an account
-	deleteAccountResponse, _ := client.DeleteAccount(host, "b483e082-9b9e-4362-b2e1-69ddc0fc5b20", 0)
-	fmt.Printf("Delete account response status code %d", deleteAccountResponse.StatusCode)
+	// delete the account
+	err = accountClient.DeleteLatest(ctx, accountID, &client.DeleteLatestOptions{IgnoreNotFound: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Deleted account")
 
 }
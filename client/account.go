@@ -0,0 +1,144 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Account is the json:api document of a single form3 account resource. It is
+// the request body of create and the response body of create and fetch.
+type Account struct {
+	Data AccountData `json:"data"`
+}
+
+// AccountList is the json:api document of a page of form3 account resources
+type AccountList struct {
+	Data  []AccountData `json:"data"`
+	Links Links         `json:"links"`
+	Meta  Meta          `json:"meta,omitempty"`
+}
+
+// Links are the json:api pagination links of a page of account resources. A
+// link is a path relative to the api root, e.g.
+// /v1/organisation/accounts?page%5Bnumber%5D=1&page%5Bsize%5D=100
+type Links struct {
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Self  string `json:"self,omitempty"`
+}
+
+// Meta is the json:api meta information of a page of account resources
+type Meta map[string]interface{}
+
+// AccountData is the form3 account resource. The same type is used by every
+// operation, so an account that was fetched can be sent back to the api.
+type AccountData struct {
+	Type           string            `json:"type"`
+	ID             string            `json:"id"`
+	OrganisationID string            `json:"organisation_id"`
+	Version        int               `json:"version,omitempty"`
+	CreatedOn      *time.Time        `json:"created_on,omitempty"`
+	ModifiedOn     *time.Time        `json:"modified_on,omitempty"`
+	Attributes     AccountAttributes `json:"attributes"`
+}
+
+// AccountAttributes holds the full form3 attribute set of an account. Country
+// and Name are required. Every other attribute is optional: it is absent
+// (the zero value, left out of the json), null or set, so that an attribute
+// that was not provided is not sent as "" or false. A fetched account tells
+// the same way which attributes the form3 api returned.
+type AccountAttributes struct {
+	Country                 string          `json:"country"`
+	BaseCurrency            OptionalString  `json:"base_currency"`
+	BankID                  OptionalString  `json:"bank_id"`
+	BankIDCode              OptionalString  `json:"bank_id_code"`
+	Bic                     OptionalString  `json:"bic"`
+	AccountNumber           OptionalString  `json:"account_number"`
+	Iban                    OptionalString  `json:"iban"`
+	Name                    []string        `json:"name"`
+	AlternativeNames        OptionalStrings `json:"alternative_names"`
+	AccountClassification   OptionalString  `json:"account_classification"`
+	JointAccount            OptionalBool    `json:"joint_account"`
+	AccountMatchingOptOut   OptionalBool    `json:"account_matching_opt_out"`
+	SecondaryIdentification OptionalString  `json:"secondary_identification"`
+	Switched                OptionalBool    `json:"switched"`
+	Status                  OptionalString  `json:"status"`
+}
+
+// MarshalJSON implements json.Marshaler, leaving out the absent attributes
+func (a AccountAttributes) MarshalJSON() ([]byte, error) {
+	o := &jsonObject{}
+	o.add("country", a.Country)
+	o.add("base_currency", a.BaseCurrency)
+	o.add("bank_id", a.BankID)
+	o.add("bank_id_code", a.BankIDCode)
+	o.add("bic", a.Bic)
+	o.add("account_number", a.AccountNumber)
+	o.add("iban", a.Iban)
+	o.add("name", a.Name)
+	o.add("alternative_names", a.AlternativeNames)
+	o.add("account_classification", a.AccountClassification)
+	o.add("joint_account", a.JointAccount)
+	o.add("account_matching_opt_out", a.AccountMatchingOptOut)
+	o.add("secondary_identification", a.SecondaryIdentification)
+	o.add("switched", a.Switched)
+	o.add("status", a.Status)
+	return o.MarshalJSON()
+}
+
+// diffAccounts compares a requested account with an existing one and returns
+// the fields that differ. The attributes absent from the requested account
+// are skipped, since the form3 api may have defaulted them.
+func diffAccounts(requested, existing *AccountData) []Mismatch {
+
+	d := &differ{}
+	d.value("type", requested.Type, existing.Type)
+	d.value("organisation_id", requested.OrganisationID, existing.OrganisationID)
+
+	r, e := requested.Attributes, existing.Attributes
+	d.value("attributes.country", r.Country, e.Country)
+	d.optional("attributes.base_currency", r.BaseCurrency, e.BaseCurrency)
+	d.optional("attributes.bank_id", r.BankID, e.BankID)
+	d.optional("attributes.bank_id_code", r.BankIDCode, e.BankIDCode)
+	d.optional("attributes.bic", r.Bic, e.Bic)
+	d.optional("attributes.account_number", r.AccountNumber, e.AccountNumber)
+	d.optional("attributes.iban", r.Iban, e.Iban)
+	d.value("attributes.name", r.Name, e.Name)
+	d.optional("attributes.alternative_names", r.AlternativeNames, e.AlternativeNames)
+	d.optional("attributes.account_classification", r.AccountClassification, e.AccountClassification)
+	d.optional("attributes.joint_account", r.JointAccount, e.JointAccount)
+	d.optional("attributes.account_matching_opt_out", r.AccountMatchingOptOut, e.AccountMatchingOptOut)
+	d.optional("attributes.secondary_identification", r.SecondaryIdentification, e.SecondaryIdentification)
+	d.optional("attributes.switched", r.Switched, e.Switched)
+	d.optional("attributes.status", r.Status, e.Status)
+	return d.mismatches
+}
+
+// differ collects the Mismatches of diffAccounts
+type differ struct {
+	mismatches []Mismatch
+}
+
+// value compares two values by their json
+func (d *differ) value(field string, requested, existing interface{}) {
+	requestedJSON, _ := json.Marshal(requested)
+	existingJSON, _ := json.Marshal(existing)
+	if string(requestedJSON) != string(existingJSON) {
+		d.mismatches = append(d.mismatches, Mismatch{Field: field, Requested: string(requestedJSON), Existing: string(existingJSON)})
+	}
+}
+
+// optional compares two optional attributes unless the requested one is
+// absent. An absent existing attribute equals a null requested one.
+func (d *differ) optional(field string, requested, existing absentable) {
+	if requested.IsAbsent() {
+		return
+	}
+	if existing.IsAbsent() {
+		d.value(field, requested, nil)
+		return
+	}
+	d.value(field, requested, existing)
+}
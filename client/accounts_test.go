@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,7 +10,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	guuid "github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -35,7 +38,7 @@ func TestCreateAccount_success(t *testing.T) {
 
 	uri := "/v1/organisation/accounts/"
 
-	var body = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var body = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
@@ -54,8 +57,8 @@ func TestCreateAccount_success(t *testing.T) {
 		t.Errorf(msg)
 	}
 	assert.Nil(t, err)
-	assert.EqualValues(t, "0673746b-8dd3-4bd2-b398-941bdf2865df", createdAccount.Cdata.ID)
-	assert.EqualValues(t, "9864746b-8dd3-4bd2-b398-941bdf2865df", createdAccount.Cdata.OrganisationID)
+	assert.EqualValues(t, "0673746b-8dd3-4bd2-b398-941bdf2865df", createdAccount.Data.ID)
+	assert.EqualValues(t, "9864746b-8dd3-4bd2-b398-941bdf2865df", createdAccount.Data.OrganisationID)
 }
 
 func TestCreateAccount_whenForm3ApiReturns500_shouldReturn500(t *testing.T) {
@@ -93,7 +96,7 @@ func TestCreateAccount_whenMarshallerFails_shouldReturnError(t *testing.T) {
 
 	uri := "/v1/organisation/accounts/"
 
-	var body = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var body = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
@@ -121,7 +124,7 @@ func TestCreateAccount_whenRequestCreatorFails_shouldReturnError(t *testing.T) {
 
 	uri := "/v1/organisation/accounts/"
 
-	var body = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var body = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df", OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df"}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
@@ -148,7 +151,7 @@ func TestGetAccount_success(t *testing.T) {
 	accountID := guuid.New().String()
 	uri := "/v1/organisation/accounts/"
 
-	var body = Account{Cdata: Cdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var body = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -210,7 +213,7 @@ func TestListAccounts_success(t *testing.T) {
 	pageSize := 30
 	uri := "/v1/organisation/accounts"
 
-	var body = GetAccountsResponse{Data: []Data{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
+	var body = AccountList{Data: []AccountData{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
 		{Type: "accounts", ID: "9673746b-8dd3-4bd2-b398-941bdf2865df"}}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
@@ -310,7 +313,7 @@ func TestGatherAccounts_WhenListAccountsReturns200AndTwoResults(t *testing.T) {
 	pageSize := 30
 	uri := "/v1/organisation/accounts"
 
-	var body = GetAccountsResponse{Data: []Data{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
+	var body = AccountList{Data: []AccountData{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
 		{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}}
 
 	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
@@ -430,14 +433,44 @@ func TestCreateRequestBody_WithAccountIdAndOrganisationId(t *testing.T) {
 	// test & validate
 	account := CreateRequestBody(actualAccountID, actualOrganisationID)
 
-	if actualAccountID != account.Cdata.ID {
-		t.Errorf("Expected %v but got %v", actualAccountID, account.Cdata.ID)
+	if actualAccountID != account.Data.ID {
+		t.Errorf("Expected %v but got %v", actualAccountID, account.Data.ID)
 	}
-	if actualOrganisationID != account.Cdata.OrganisationID {
-		t.Errorf("Expected %v but got %v", actualOrganisationID, account.Cdata.OrganisationID)
+	if actualOrganisationID != account.Data.OrganisationID {
+		t.Errorf("Expected %v but got %v", actualOrganisationID, account.Data.OrganisationID)
 	}
 }
 
+func TestAccount_fetchedAccountCanBeSentBackToCreate(t *testing.T) {
+	// prepare
+	fetched := `{"data":{"type":"accounts","id":"0673746b-8dd3-4bd2-b398-941bdf2865df",
+		"organisation_id":"9864746b-8dd3-4bd2-b398-941bdf2865df","version":2,
+		"created_on":"2021-01-05T10:01:02.123Z","modified_on":"2021-01-06T11:01:02.123Z",
+		"attributes":{"country":"GB","base_currency":"GBP","bank_id":"400300","bank_id_code":"GBDSC",
+		"bic":"NWBKGB22","account_number":"41426819","iban":"GB11NWBK40030041426819",
+		"name":["Samantha Holder"],"alternative_names":["Sam Holder"],"account_classification":"Personal",
+		"joint_account":true,"account_matching_opt_out":false,"secondary_identification":"A1B2C3D4",
+		"switched":true,"status":"confirmed"}}}`
+
+	// test
+	account := &Account{}
+	err := json.Unmarshal([]byte(fetched), account)
+	jsonBytes, _ := json.Marshal(account)
+	sentBack := &Account{}
+	err2 := json.Unmarshal(jsonBytes, sentBack)
+
+	// validate
+	assert.Nil(t, err)
+	assert.Nil(t, err2)
+	assert.EqualValues(t, 2, account.Data.Version)
+	assert.EqualValues(t, 2021, account.Data.CreatedOn.Year())
+	assert.EqualValues(t, []string{"Samantha Holder"}, account.Data.Attributes.Name)
+	assert.EqualValues(t, "GB11NWBK40030041426819", account.Data.Attributes.Iban.Value())
+	assert.EqualValues(t, "confirmed", account.Data.Attributes.Status.Value())
+	assert.True(t, account.Data.Attributes.Switched.Value())
+	assert.EqualValues(t, account, sentBack)
+}
+
 func TestUnmarshallCreateAccountResponse_success(t *testing.T) {
 	// prepare
 	actualAccountID := guuid.New().String()
@@ -458,8 +491,8 @@ func TestUnmarshallCreateAccountResponse_success(t *testing.T) {
 
 	// validate
 	assert.Nil(t, err)
-	assert.EqualValues(t, accountFromResponse.Cdata.ID, actualAccountID)
-	assert.EqualValues(t, accountFromResponse.Cdata.OrganisationID, actualOrganisationID)
+	assert.EqualValues(t, accountFromResponse.Data.ID, actualAccountID)
+	assert.EqualValues(t, accountFromResponse.Data.OrganisationID, actualOrganisationID)
 }
 
 func TestUnmarshallCreateAccountResponse_whenUnmarshallerFails_returnsError(t *testing.T) {
@@ -492,7 +525,7 @@ func TestUnmarshallCreateAccountResponse_whenUnmarshallerFails_returnsError(t *t
 
 func TestUnmarshallGetAccountResponse_success(t *testing.T) {
 	// prepare
-	var getAccountResponse = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var getAccountResponse = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
 	jsonBytes, _ := json.Marshal(getAccountResponse)
 	body := ioutil.NopCloser(bytes.NewReader(jsonBytes))
 
@@ -505,13 +538,13 @@ func TestUnmarshallGetAccountResponse_success(t *testing.T) {
 
 	// test & validate
 	accountFromResponse, err := UnmarshallGetAccountResponse(response)
-	assert.EqualValues(t, "0673746b-8dd3-4bd2-b398-941bdf2865df", accountFromResponse.Gdata.ID)
+	assert.EqualValues(t, "0673746b-8dd3-4bd2-b398-941bdf2865df", accountFromResponse.Data.ID)
 	assert.Nil(t, err)
 }
 
 func TestUnmarshallGetAccountResponse_whenUnmarshallerFails_shouldReturnError(t *testing.T) {
 	// prepare
-	var getAccountResponse = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var getAccountResponse = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
 	jsonBytes, _ := json.Marshal(getAccountResponse)
 	body := ioutil.NopCloser(bytes.NewReader(jsonBytes))
 
@@ -534,7 +567,7 @@ func TestUnmarshallGetAccountResponse_whenUnmarshallerFails_shouldReturnError(t
 
 func TestUnmarshallGetAccountResponse_whenIOResponseBodyReaderFails_shouldReturnError(t *testing.T) {
 	// prepare
-	var getAccountResponse = GetAccountResponse{Gdata: Gdata{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
+	var getAccountResponse = Account{Data: AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}
 	jsonBytes, _ := json.Marshal(getAccountResponse)
 	body := ioutil.NopCloser(bytes.NewReader(jsonBytes))
 
@@ -589,7 +622,7 @@ func TestUnmarshallCreateAccountResponse_whenIOResponseBodyReaderFails_returnsEr
 
 func TestUnmarshallGetAccountsResponse_whenIOResponseBodyReaderFails_returnsError(t *testing.T) {
 	// prepare
-	var getAccountsResponse = GetAccountsResponse{Data: []Data{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
+	var getAccountsResponse = AccountList{Data: []AccountData{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
 		{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"}}}
 
 	jsonBytes, _ := json.Marshal(getAccountsResponse)
@@ -613,6 +646,626 @@ func TestUnmarshallGetAccountsResponse_whenIOResponseBodyReaderFails_returnsErro
 	assert.EqualValues(t, "IOResponseBodyReader faillure", fmt.Sprint(err))
 }
 
+func TestCreate_success(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	uri := "/v1/organisation/accounts"
+
+	var contentType string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		sent := &Account{}
+		json.NewDecoder(r.Body).Decode(sent)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sent)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	created, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "application/vnd.api+json", contentType)
+	assert.EqualValues(t, account.Data.ID, created.ID)
+	assert.EqualValues(t, "GBDSC", created.Attributes.BankIDCode.Value())
+}
+
+func TestCreate_whenForm3ApiReturns500_shouldReturnError(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test & validate
+	created, err := c.Create(context.Background(), &account.Data)
+
+	assert.Nil(t, created)
+	assert.NotNil(t, err)
+}
+
+func TestCreate_whenMarshallerFails_shouldReturnError(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	c, _ := NewClient("http://localhost:8080")
+
+	Marshaller = func(v interface{}) ([]byte, error) {
+		return nil, errors.New("Marshaller faillure")
+	}
+	defer restoreInits()
+
+	// test & validate
+	created, err := c.Create(context.Background(), &account.Data)
+
+	assert.Nil(t, created)
+	assert.EqualValues(t, "Marshaller faillure", fmt.Sprint(err))
+}
+
+func TestFetch_success(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	var path string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Account{Data: AccountData{Type: "accounts", ID: accountID, Version: 3}})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	account, err := c.Fetch(context.Background(), accountID)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "/v1/organisation/accounts/"+accountID, path)
+	assert.EqualValues(t, accountID, account.ID)
+	assert.EqualValues(t, 3, account.Version)
+}
+
+func TestFetch_whenForm3ApiReturns404_shouldReturnErrorAndCloseBody(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+	transport := &bodyTrackingTransport{}
+	c, _ := NewClient(server.URL, WithHTTPClient(&http.Client{Transport: transport}))
+
+	// test
+	account, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	assert.Nil(t, account)
+	assert.NotNil(t, err)
+	assert.True(t, transport.closed)
+}
+
+func TestList_success(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	var query string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{Data: []AccountData{{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df"},
+			{Type: "accounts", ID: "9673746b-8dd3-4bd2-b398-941bdf2865df"}}})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.List(context.Background(), &ListOptions{PageNumber: 2, PageSize: 10})
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "page%5Bnumber%5D=2&page%5Bsize%5D=10", query)
+	assert.EqualValues(t, 2, len(accounts))
+	assert.EqualValues(t, "9673746b-8dd3-4bd2-b398-941bdf2865df", accounts[1].ID)
+}
+
+func TestList_withFilter_shouldSendEscapedFilterParameters(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	var query url.Values
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	_, err := c.List(context.Background(), &ListOptions{PageSize: 10,
+		Filter: &AccountFilter{Iban: "GB11 NWBK&page[size]=1", Country: "GB"}})
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, url.Values{
+		"page[number]":    {"0"},
+		"page[size]":      {"10"},
+		"filter[iban]":    {"GB11 NWBK&page[size]=1"},
+		"filter[country]": {"GB"},
+	}, query)
+}
+
+func TestList_withNilOptions_shouldNotSendPagingParameters(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	query := "not called"
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.List(context.Background(), nil)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", query)
+	assert.EqualValues(t, 0, len(accounts))
+}
+
+func TestDelete_success(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	var method, query string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	err := c.Delete(context.Background(), accountID, 4)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.MethodDelete, method)
+	assert.EqualValues(t, "version=4", query)
+}
+
+func TestDelete_whenForm3ApiReturns409_shouldReturnError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test & validate
+	err := c.Delete(context.Background(), guuid.New().String(), 0)
+
+	assert.NotNil(t, err)
+	assert.True(t, IsConflict(err))
+	assert.True(t, IsVersionConflict(err))
+}
+
+func TestCreateOrGet_whenSameAccountExists_shouldReturnIt(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+
+	var methods []string
+	server := newTestServer("/v1/organisation/", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		existing := *account
+		existing.Data.Version = 1
+		// defaulted by the form3 api, absent from the request
+		existing.Data.Attributes.JointAccount = Bool(false)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(existing)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	existing, err := c.CreateOrGet(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{http.MethodPost, http.MethodGet}, methods)
+	assert.EqualValues(t, account.Data.ID, existing.ID)
+	assert.EqualValues(t, 1, existing.Version)
+}
+
+func TestCreateOrGet_whenOtherAccountExists_shouldReturnMismatchError(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+
+	server := newTestServer("/v1/organisation/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		existing := *account
+		existing.Data.Attributes.Bic = String("BARCGB22")
+		existing.Data.Attributes.AlternativeNames = OptionalStrings{}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(existing)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	existing, err := c.CreateOrGet(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, existing)
+	var mismatchError *AccountMismatchError
+	assert.True(t, errors.As(err, &mismatchError))
+	assert.EqualValues(t, []Mismatch{
+		{Field: "attributes.bic", Requested: `"NWBKGB22"`, Existing: `"BARCGB22"`},
+		{Field: "attributes.alternative_names", Requested: `["Sam Holder"]`, Existing: "null"},
+	}, mismatchError.Mismatches)
+	assert.Contains(t, err.Error(), `attributes.bic is "BARCGB22", requested "NWBKGB22"`)
+}
+
+func TestCreateOrGet_whenConflictIsNotAboutTheID_shouldReturnConflict(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+
+	server := newTestServer("/v1/organisation/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	_, err := c.CreateOrGet(context.Background(), &account.Data)
+
+	// validate
+	assert.True(t, IsConflict(err))
+}
+
+func TestDeleteLatest_whenVersionMoves_shouldRetryWithFetchedVersion(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	version := 3
+	var deletedVersions []string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Account{Data: AccountData{Type: "accounts", ID: accountID, Version: version}})
+			// the account is updated between the fetch and the first delete
+			version = 4
+			return
+		}
+		deletedVersions = append(deletedVersions, r.URL.Query().Get("version"))
+		if r.URL.Query().Get("version") != fmt.Sprint(version) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	err := c.DeleteLatest(context.Background(), accountID, nil)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"3", "4"}, deletedVersions)
+}
+
+func TestDeleteLatest_whenVersionKeepsMoving_shouldReturnVersionConflictError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	deletes := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Account{})
+			return
+		}
+		deletes++
+		w.WriteHeader(http.StatusConflict)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	err := c.DeleteLatest(context.Background(), guuid.New().String(), &DeleteLatestOptions{Attempts: 2})
+
+	// validate
+	assert.True(t, IsVersionConflict(err))
+	assert.EqualValues(t, 2, deletes)
+}
+
+func TestDeleteLatest_whenAccountDoesNotExist(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test & validate
+	err := c.DeleteLatest(context.Background(), guuid.New().String(), nil)
+	assert.True(t, IsNotFound(err))
+
+	err = c.DeleteLatest(context.Background(), guuid.New().String(), &DeleteLatestOptions{IgnoreNotFound: true})
+	assert.Nil(t, err)
+}
+
+func TestUpdateAccount_success(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	var method, path, contentType string
+	var body map[string]interface{}
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Account{Data: AccountData{Type: "accounts", ID: accountID, Version: 1,
+			Attributes: AccountAttributes{Country: "GB", Name: []string{"Samantha Holder"}}}})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	patch := &AccountPatch{Name: Strings("Samantha Holder"), JointAccount: Bool(false), Bic: NullString()}
+
+	// test
+	account, err := c.UpdateAccount(context.Background(), accountID, 0, patch)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.MethodPatch, method)
+	assert.EqualValues(t, uri+accountID, path)
+	assert.EqualValues(t, "application/vnd.api+json", contentType)
+	assert.EqualValues(t, map[string]interface{}{"data": map[string]interface{}{
+		"type":    "accounts",
+		"id":      accountID,
+		"version": float64(0),
+		"attributes": map[string]interface{}{
+			"name":          []interface{}{"Samantha Holder"},
+			"joint_account": false,
+			"bic":           nil,
+		},
+	}}, body)
+	assert.EqualValues(t, 1, account.Version)
+	assert.EqualValues(t, "Samantha Holder", account.Attributes.Name[0])
+}
+
+func TestUpdateAccount_whenVersionMismatch_shouldReturnVersionConflictError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error_message":"invalid version"}`))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	account, err := c.UpdateAccount(context.Background(), guuid.New().String(), 3, &AccountPatch{})
+
+	// validate
+	assert.Nil(t, account)
+	assert.True(t, IsVersionConflict(err))
+	assert.True(t, IsConflict(err))
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, "invalid version", apiError.ErrorMessage)
+}
+
+func TestUpdateAccount_whenServerFails_shouldNotRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	_, err := c.UpdateAccount(context.Background(), guuid.New().String(), 0, nil)
+
+	// validate
+	assert.EqualValues(t, 1, requests)
+	assert.True(t, IsRetryable(err))
+	assert.False(t, IsVersionConflict(err))
+}
+
+func TestGetAccountWithContext_shouldNotUseRequestCreator(t *testing.T) {
+	// prepare
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	RequestCreator = func(method, url string, body io.Reader) (*http.Request, error) {
+		return nil, errors.New("RequestCreator faillure")
+	}
+	defer restoreInits()
+
+	// test
+	response, err := c.GetAccountWithContext(context.Background(), guuid.New().String())
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	response.Body.Close()
+}
+
+func TestFetch_whenContextIsCancelled_shouldReturnContextError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	called := false
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// test
+	account, err := c.Fetch(ctx, guuid.New().String())
+
+	// validate
+	assert.Nil(t, account)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, called)
+}
+
+func TestGatherAccountsWithContext_whenContextIsCancelled_shouldReturnPartialResults(t *testing.T) {
+	// prepare
+	restoreInits()
+	pageSize := 2
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			// cancel while the request of the second page is in flight
+			cancel()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.GatherAccountsWithContext(ctx, pageSize)
+
+	// validate
+	assert.True(t, err == ctx.Err(), err)
+	assert.EqualValues(t, 2, requests)
+	assert.EqualValues(t, 2, len(accounts))
+}
+
+func TestGatherAccountsWithContext_whenPageFails_shouldReturnPartialResultsAndError(t *testing.T) {
+	// prepare
+	restoreInits()
+	pageSize := 2
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2)}
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page[number]") == "1" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithRetryPolicy(NoRetry))
+
+	// test
+	accounts, err := c.GatherAccountsWithContext(context.Background(), pageSize)
+
+	// validate
+	assert.EqualValues(t, 2, len(accounts))
+	assert.True(t, IsRetryable(err))
+}
+
+// bodyTrackingTransport records whether the body of the last response was closed
+type bodyTrackingTransport struct {
+	closed bool
+}
+
+func (t *bodyTrackingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	t.closed = false
+	response.Body = &trackedBody{ReadCloser: response.Body, closed: &t.closed}
+	return response, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	closed *bool
+}
+
+func (b *trackedBody) Close() error {
+	*b.closed = true
+	return b.ReadCloser.Close()
+}
+
 // integration tests
 
 func TestClient_createAccount_works(t *testing.T) {
@@ -638,13 +1291,13 @@ func TestClient_createAccount_works(t *testing.T) {
 		t.Errorf(msg)
 	}
 	assert.Nil(t, err)
-	assert.EqualValues(t, accountID, createdAccount.Cdata.ID)
-	assert.EqualValues(t, organizationID, createdAccount.Cdata.OrganisationID)
-	assert.EqualValues(t, "GB", createdAccount.Cdata.Cattributes.Country)
-	assert.EqualValues(t, "GBP", createdAccount.Cdata.Cattributes.BaseCurrency)
-	assert.EqualValues(t, "400300", createdAccount.Cdata.Cattributes.BankID)
-	assert.EqualValues(t, "GBDSC", createdAccount.Cdata.Cattributes.BankIDCode)
-	assert.EqualValues(t, "NWBKGB22", createdAccount.Cdata.Cattributes.Bic)
+	assert.EqualValues(t, accountID, createdAccount.Data.ID)
+	assert.EqualValues(t, organizationID, createdAccount.Data.OrganisationID)
+	assert.EqualValues(t, "GB", createdAccount.Data.Attributes.Country)
+	assert.EqualValues(t, "GBP", createdAccount.Data.Attributes.BaseCurrency.Value())
+	assert.EqualValues(t, "400300", createdAccount.Data.Attributes.BankID.Value())
+	assert.EqualValues(t, "GBDSC", createdAccount.Data.Attributes.BankIDCode.Value())
+	assert.EqualValues(t, "NWBKGB22", createdAccount.Data.Attributes.Bic.Value())
 }
 
 func TestClient_listAccounts_works(t *testing.T) {
@@ -713,12 +1366,12 @@ func TestClient_getAccount_works(t *testing.T) {
 	assert.Nil(t, error)
 	assert.Nil(t, error2)
 	assert.EqualValues(t, 200, response.StatusCode)
-	assert.EqualValues(t, accountID, getAccountResponse.Gdata.ID)
-	assert.EqualValues(t, organizationID, getAccountResponse.Gdata.OrganisationID)
-	assert.EqualValues(t, "accounts", getAccountResponse.Gdata.Type)
-	assert.EqualValues(t, "400300", getAccountResponse.Gdata.Gattributes.BankID)
-	assert.EqualValues(t, "GBDSC", getAccountResponse.Gdata.Gattributes.BankIDCode)
-	assert.EqualValues(t, "NWBKGB22", getAccountResponse.Gdata.Gattributes.Bic)
-	assert.EqualValues(t, "GBP", getAccountResponse.Gdata.Gattributes.BaseCurrency)
-	assert.EqualValues(t, "GB", getAccountResponse.Gdata.Gattributes.Country)
+	assert.EqualValues(t, accountID, getAccountResponse.Data.ID)
+	assert.EqualValues(t, organizationID, getAccountResponse.Data.OrganisationID)
+	assert.EqualValues(t, "accounts", getAccountResponse.Data.Type)
+	assert.EqualValues(t, "400300", getAccountResponse.Data.Attributes.BankID.Value())
+	assert.EqualValues(t, "GBDSC", getAccountResponse.Data.Attributes.BankIDCode.Value())
+	assert.EqualValues(t, "NWBKGB22", getAccountResponse.Data.Attributes.Bic.Value())
+	assert.EqualValues(t, "GBP", getAccountResponse.Data.Attributes.BaseCurrency.Value())
+	assert.EqualValues(t, "GB", getAccountResponse.Data.Attributes.Country)
 }
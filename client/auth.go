@@ -0,0 +1,47 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Authenticator authenticates the requests of a Client, e.g. the Signer of
+// the HTTP Signatures of the form3 api. Authenticate is called for every
+// attempt of a request, right before it is sent, so that time bound
+// credentials such as the Date of a signature are fresh on a retry.
+type Authenticator interface {
+	// Authenticate adds the credentials to the request, usually as headers.
+	// An error stops the request, which is not sent.
+	Authenticate(request *http.Request) error
+}
+
+// Reauthenticator is an Authenticator whose credentials can be refused before
+// they expire, e.g. a revoked token. When an attempt is answered with 401
+// Unauthorized the Client calls Reauthenticate and, when it returns true,
+// sends the request once more with the credentials of a new Authenticate.
+type Reauthenticator interface {
+	Authenticator
+	// Reauthenticate drops the credentials the request was refused with and
+	// reports whether new ones can be tried
+	Reauthenticate(request *http.Request) bool
+}
+
+// WithAuthenticator sets the Authenticator of the requests of the Client.
+// Without it the requests are sent unauthenticated, as the local form3 api
+// accepts them.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(c *Client) {
+		c.authenticator = authenticator
+	}
+}
+
+// reauthenticate reports whether an attempt answered with 401 Unauthorized is
+// sent again with new credentials, which the Authenticator of the Client
+// allows once per request when it is a Reauthenticator
+func (c *Client) reauthenticate(request *http.Request, response *http.Response) bool {
+
+	if response == nil || response.StatusCode != http.StatusUnauthorized || !canReplay(request) {
+		return false
+	}
+	reauthenticator, ok := c.authenticator.(Reauthenticator)
+	return ok && reauthenticator.Reauthenticate(request)
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	guuid "github.com/google/uuid"
+)
+
+// AccountBuilder builds the AccountData of an account of a country, created
+// with NewAccount. The With methods set the attributes and return the
+// builder, so that the calls can be chained; Build checks the account.
+type AccountBuilder struct {
+	account AccountData
+}
+
+// NewAccount starts an account of the given ISO 3166 country. The attributes
+// that follow from the country are filled in: the bank id code and the base
+// currency of the countries the form3 api supports, which the With methods
+// can still override.
+func NewAccount(country string) *AccountBuilder {
+
+	b := &AccountBuilder{account: AccountData{
+		Type:       "accounts",
+		Attributes: AccountAttributes{Country: country},
+	}}
+	if rule, ok := countryRules[country]; ok {
+		if rule.bankIDCode != "" {
+			b.account.Attributes.BankIDCode = String(rule.bankIDCode)
+		}
+		b.account.Attributes.BaseCurrency = String(rule.currency)
+	}
+	return b
+}
+
+// WithID sets the id of the account. Without it Build generates a new uuid.
+func (b *AccountBuilder) WithID(id string) *AccountBuilder {
+	b.account.ID = id
+	return b
+}
+
+// WithOrganisationID sets the organisation id of the account
+func (b *AccountBuilder) WithOrganisationID(organisationID string) *AccountBuilder {
+	b.account.OrganisationID = organisationID
+	return b
+}
+
+// WithBaseCurrency sets the ISO 4217 base currency of the account
+func (b *AccountBuilder) WithBaseCurrency(currency string) *AccountBuilder {
+	b.account.Attributes.BaseCurrency = String(currency)
+	return b
+}
+
+// WithBankID sets the bank id of the account, e.g. the sort code of a GB account
+func (b *AccountBuilder) WithBankID(bankID string) *AccountBuilder {
+	b.account.Attributes.BankID = String(bankID)
+	return b
+}
+
+// WithBankIDCode sets the bank id code of the account
+func (b *AccountBuilder) WithBankIDCode(bankIDCode string) *AccountBuilder {
+	b.account.Attributes.BankIDCode = String(bankIDCode)
+	return b
+}
+
+// WithBic sets the BIC of the account
+func (b *AccountBuilder) WithBic(bic string) *AccountBuilder {
+	b.account.Attributes.Bic = String(bic)
+	return b
+}
+
+// WithAccountNumber sets the account number. Without it the form3 api
+// generates one.
+func (b *AccountBuilder) WithAccountNumber(accountNumber string) *AccountBuilder {
+	b.account.Attributes.AccountNumber = String(accountNumber)
+	return b
+}
+
+// WithIban sets the iban of the account
+func (b *AccountBuilder) WithIban(iban string) *AccountBuilder {
+	b.account.Attributes.Iban = String(iban)
+	return b
+}
+
+// WithNames sets the name lines of the account holder
+func (b *AccountBuilder) WithNames(names ...string) *AccountBuilder {
+	b.account.Attributes.Name = names
+	return b
+}
+
+// WithAlternativeNames sets the alternative name lines of the account holder
+func (b *AccountBuilder) WithAlternativeNames(names ...string) *AccountBuilder {
+	b.account.Attributes.AlternativeNames = Strings(names...)
+	return b
+}
+
+// WithAccountClassification sets the classification, Personal or Business
+func (b *AccountBuilder) WithAccountClassification(classification string) *AccountBuilder {
+	b.account.Attributes.AccountClassification = String(classification)
+	return b
+}
+
+// WithJointAccount sets whether the account is held jointly
+func (b *AccountBuilder) WithJointAccount(jointAccount bool) *AccountBuilder {
+	b.account.Attributes.JointAccount = Bool(jointAccount)
+	return b
+}
+
+// WithAccountMatchingOptOut sets whether the account opts out of account matching
+func (b *AccountBuilder) WithAccountMatchingOptOut(optOut bool) *AccountBuilder {
+	b.account.Attributes.AccountMatchingOptOut = Bool(optOut)
+	return b
+}
+
+// WithSecondaryIdentification sets the secondary identification of the account
+func (b *AccountBuilder) WithSecondaryIdentification(identification string) *AccountBuilder {
+	b.account.Attributes.SecondaryIdentification = String(identification)
+	return b
+}
+
+// WithSwitched sets whether the account has been switched
+func (b *AccountBuilder) WithSwitched(switched bool) *AccountBuilder {
+	b.account.Attributes.Switched = Bool(switched)
+	return b
+}
+
+// Build returns the account after checking it with Validate and the given
+// validators, so that a required attribute the country needs, or one it does
+// not support, is reported as ValidationErrors instead of returning a half
+// populated account.
+func (b *AccountBuilder) Build(validators ...AccountValidator) (*AccountData, error) {
+
+	account := b.account
+	if account.ID == "" {
+		account.ID = guuid.New().String()
+	}
+
+	if err := account.Validate(validators...); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
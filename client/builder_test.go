@@ -0,0 +1,88 @@
+package client
+
+import (
+	"testing"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccount_shouldBuildAnAccountOfTheCountry(t *testing.T) {
+	// test
+	account, err := NewAccount("GB").
+		WithID("0673746b-8dd3-4bd2-b398-941bdf2865df").
+		WithOrganisationID("9864746b-8dd3-4bd2-b398-941bdf2865df").
+		WithBankID("400300").
+		WithBic("NWBKGB22").
+		WithAccountNumber("41426819").
+		WithNames("Samantha Holder").
+		WithAlternativeNames("Sam Holder").
+		WithAccountClassification("Personal").
+		WithJointAccount(false).
+		Build()
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "accounts", account.Type)
+	assert.EqualValues(t, "0673746b-8dd3-4bd2-b398-941bdf2865df", account.ID)
+	assert.EqualValues(t, AccountAttributes{
+		Country:               "GB",
+		BaseCurrency:          String("GBP"),
+		BankID:                String("400300"),
+		BankIDCode:            String("GBDSC"),
+		Bic:                   String("NWBKGB22"),
+		AccountNumber:         String("41426819"),
+		Name:                  []string{"Samantha Holder"},
+		AlternativeNames:      Strings("Sam Holder"),
+		AccountClassification: String("Personal"),
+		JointAccount:          Bool(false),
+	}, account.Attributes)
+}
+
+func TestNewAccount_shouldGenerateTheID(t *testing.T) {
+	// test
+	builder := NewAccount("NL").
+		WithOrganisationID("9864746b-8dd3-4bd2-b398-941bdf2865df").
+		WithBic("ABNANL2A").
+		WithIban("NL91ABNA0417164300").
+		WithNames("Samantha Holder")
+	first, err := builder.Build()
+	assert.Nil(t, err)
+	second, err := builder.Build()
+	assert.Nil(t, err)
+
+	// validate
+	_, err = guuid.Parse(first.ID)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.True(t, first.Attributes.BankIDCode.IsAbsent())
+	assert.EqualValues(t, "EUR", first.Attributes.BaseCurrency.Value())
+}
+
+func TestNewAccount_shouldReturnTheViolationsOfTheCountry(t *testing.T) {
+	// test
+	account, err := NewAccount("NL").
+		WithOrganisationID("9864746b-8dd3-4bd2-b398-941bdf2865df").
+		WithBankID("ABNA").
+		WithNames("Samantha Holder").
+		Build()
+
+	// validate
+	assert.Nil(t, account)
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.bank_id", Message: "is not supported for country NL"},
+		{Field: "attributes.bic", Message: "is required for country NL"},
+	}, err)
+
+	// test
+	account, err = NewAccount("DE").
+		WithOrganisationID("9864746b-8dd3-4bd2-b398-941bdf2865df").
+		WithNames("Samantha Holder").
+		Build()
+
+	// validate
+	assert.Nil(t, account)
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.bank_id", Message: "is required for country DE"},
+	}, err)
+}
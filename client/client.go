@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is sent with every request unless overridden with WithUserAgent
+const DefaultUserAgent = "f3-assignment-client/1.0"
+
+const accountsPath = "/v1/organisation/accounts"
+
+// Client is a configured form3 account api client. It is constructed once with
+// NewClient and is safe for concurrent use by multiple goroutines.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	header     http.Header
+	userAgent  string
+
+	retryPolicy           RetryPolicy
+	rateLimiter           *RateLimiter
+	operationRateLimiters map[Operation]*RateLimiter
+
+	validators      []AccountValidator
+	authenticator   Authenticator
+	tlsConfig       *TLSConfig
+	transportConfig *TransportConfig
+	middlewares     []Middleware
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to send requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the overall time limit of each request
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHeader adds a header that is sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// NewClient creates a Client for the form3 api served at baseURL, e.g. http://localhost:8080
+func NewClient(baseURL string, options ...Option) (*Client, error) {
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: missing host", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Transport: sharedTransport},
+		header:     http.Header{},
+		userAgent:  DefaultUserAgent,
+
+		retryPolicy:           DefaultRetryPolicy(),
+		operationRateLimiters: map[Operation]*RateLimiter{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.httpClient == nil {
+		return nil, errors.New("http client must not be nil")
+	}
+	if c.transportConfig != nil {
+		// copy so that a shared http.Client passed with WithHTTPClient is not modified
+		httpClient := *c.httpClient
+		httpClient.Transport = NewTransport(*c.transportConfig)
+		c.httpClient = &httpClient
+	}
+	if c.tlsConfig != nil {
+		if err := c.configureTLS(); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.middlewares) > 0 {
+		httpClient := *c.httpClient
+		httpClient.Transport = chainMiddlewares(httpClient.Transport, c.middlewares)
+		c.httpClient = &httpClient
+	}
+	if c.timeout > 0 {
+		// copy so that a shared http.Client passed with WithHTTPClient is not modified
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+
+	return c, nil
+}
+
+// BaseURL returns the base url the Client sends requests to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// hostClient returns an unconfigured Client, which sends every request once,
+// for the package level functions that take a raw host string
+func hostClient(host string) *Client {
+	return &Client{
+		baseURL:    host,
+		httpClient: &http.Client{Transport: sharedTransport},
+		header:     http.Header{},
+
+		retryPolicy: NoRetry,
+	}
+}
+
+// newRequest creates a request for the given path relative to the base url,
+// with the default headers and user agent of the Client
+func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+
+	request, err := RequestCreator(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setHeaders(request)
+	return request, nil
+}
+
+// newRequestWithContext is newRequest for a request bound to ctx
+func (c *Client) newRequestWithContext(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setHeaders(request)
+	return request, nil
+}
+
+// setHeaders adds the default headers and user agent of the Client to the request
+func (c *Client) setHeaders(request *http.Request) {
+	for key, values := range c.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	if c.userAgent != "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
+}
+
+// decodeResponse drains and closes the body of the response, so that the
+// connection is reused. A response with a non 2xx status is turned into an
+// *APIError, or a *RateLimitError when the request was rate limited,
+// otherwise the json body is decoded into v unless v is nil.
+func decodeResponse(response *http.Response, v interface{}) error {
+	defer drainAndClose(response.Body)
+
+	if err := responseError(response); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// responseError returns a RateLimitError or an APIError for a non 2xx
+// response, nil otherwise
+func responseError(response *http.Response) error {
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if isRateLimited(response) {
+			retryAfter, _ := parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+			return &RateLimitError{APIError: newAPIError(response), RetryAfter: retryAfter}
+		}
+		return newAPIError(response)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// restoreInits resets the variables of inits.go that other tests may have mocked
+func restoreInits() {
+	Marshaller = json.Marshal
+	Unmarshaller = json.Unmarshal
+	RequestCreator = http.NewRequest
+	IOResponseBodyReader = ioutil.ReadAll
+}
+
+func TestNewClient_withDefaults(t *testing.T) {
+	// test
+	c, err := NewClient("http://localhost:8080/")
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "http://localhost:8080", c.BaseURL())
+	assert.EqualValues(t, DefaultUserAgent, c.userAgent)
+	assert.NotNil(t, c.httpClient)
+}
+
+func TestNewClient_whenBaseURLIsInvalid_shouldReturnError(t *testing.T) {
+	for _, baseURL := range []string{"localhost:8080", "ftp://localhost", "http://", "://"} {
+		c, err := NewClient(baseURL)
+
+		assert.Nil(t, c, baseURL)
+		assert.NotNil(t, err, baseURL)
+	}
+}
+
+func TestNewClient_whenHTTPClientIsNil_shouldReturnError(t *testing.T) {
+	c, err := NewClient("http://localhost:8080", WithHTTPClient(nil))
+
+	assert.Nil(t, c)
+	assert.NotNil(t, err)
+}
+
+func TestNewClient_withTimeout_shouldNotModifySharedHTTPClient(t *testing.T) {
+	// prepare
+	shared := &http.Client{}
+
+	// test
+	c, err := NewClient("http://localhost:8080", WithHTTPClient(shared), WithTimeout(5*time.Second))
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 5*time.Second, c.httpClient.Timeout)
+	assert.EqualValues(t, 0, shared.Timeout)
+}
+
+func TestNewClient_sendsDefaultHeadersAndUserAgent(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	var userAgent, tenant string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		tenant = r.Header.Get("X-Tenant")
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	c, _ := NewClient(server.URL, WithUserAgent("batch-job/2.0"), WithHeader("X-Tenant", "acme"))
+
+	// test
+	response, err := c.GetAccount(guuid.New().String())
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.EqualValues(t, "batch-job/2.0", userAgent)
+	assert.EqualValues(t, "acme", tenant)
+}
+
+func TestNewClient_withHTTPClient_usesIt(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{})
+	})
+	defer server.Close()
+
+	transport := &countingTransport{}
+	c, _ := NewClient(server.URL, WithHTTPClient(&http.Client{Transport: transport}))
+
+	// test
+	response, err := c.ListAccounts(0, 10)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, response.StatusCode)
+	assert.EqualValues(t, 1, transport.requests)
+}
+
+// countingTransport counts the requests sent through http.DefaultTransport
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -2,58 +2,31 @@ package client
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"net/http"
 )
 
-// Account ...
-type Account struct {
-	Cdata Cdata `json:"data"`
-}
-
-// Cattributes ...
-type Cattributes struct {
-	Country                 string   `json:"country"`
-	BaseCurrency            string   `json:"base_currency"`
-	BankID                  string   `json:"bank_id"`
-	BankIDCode              string   `json:"bank_id_code"`
-	Bic                     string   `json:"bic"`
-	Name                    []string `json:"name"`
-	AlternativeNames        []string `json:"alternative_names"`
-	AccountClassification   string   `json:"account_classification"`
-	JointAccount            bool     `json:"joint_account"`
-	AccountMatchingOptOut   bool     `json:"account_matching_opt_out"`
-	SecondaryIdentification string   `json:"secondary_identification"`
-}
-
-// Cdata ...
-type Cdata struct {
-	Type           string      `json:"type"`
-	ID             string      `json:"id"`
-	OrganisationID string      `json:"organisation_id"`
-	Cattributes    Cattributes `json:"attributes"`
-}
-
 // CreateRequestBody creates a struct of type Account
+//
+// Deprecated: it always creates the same GB account holder; use NewAccount,
+// which builds and checks an account of any supported country
 func CreateRequestBody(accountID, organisationID string) (account *Account) {
 
 	account = &Account{
-		Cdata: Cdata{
+		Data: AccountData{
 			Type:           "accounts",
 			ID:             accountID,
 			OrganisationID: organisationID,
-			Cattributes: Cattributes{
+			Attributes: AccountAttributes{
 				Country:                 "GB",
-				BaseCurrency:            "GBP",
-				BankID:                  "400300",
-				BankIDCode:              "GBDSC",
-				Bic:                     "NWBKGB22",
+				BaseCurrency:            String("GBP"),
+				BankID:                  String("400300"),
+				BankIDCode:              String("GBDSC"),
+				Bic:                     String("NWBKGB22"),
 				Name:                    []string{"Samantha Holder"},
-				AlternativeNames:        []string{"Sam Holder"},
-				AccountClassification:   "Personal",
-				JointAccount:            false,
-				AccountMatchingOptOut:   false,
-				SecondaryIdentification: "A1B2C3D4",
+				AlternativeNames:        Strings("Sam Holder"),
+				AccountClassification:   String("Personal"),
+				SecondaryIdentification: String("A1B2C3D4"),
 			},
 		},
 	}
@@ -61,29 +34,99 @@ func CreateRequestBody(accountID, organisationID string) (account *Account) {
 }
 
 // CreateAccount calls the form3 api with the specified accountID and organizationID
+//
+// Deprecated: construct a Client with NewClient and use Client.CreateAccount
 func CreateAccount(host string, account *Account) (*http.Response, error) {
+	return hostClient(host).CreateAccount(account)
+}
 
-	fmt.Println("in CreateAccount.go with id", account.Cdata.ID)
+// CreateAccount calls the form3 api to create the given account
+func (c *Client) CreateAccount(account *Account) (*http.Response, error) {
 
 	jsonBytes, err := Marshaller(account)
 	if err != nil {
 		return nil, err
 	}
 
-	uri := "/v1/organisation/accounts"
+	request, err := c.newRequest(http.MethodPost, accountsPath, bytes.NewReader(jsonBytes))
+	if err != nil {
+		return nil, err
+	}
 
-	request, err := RequestCreator(http.MethodPost, host+uri, bytes.NewReader(jsonBytes))
+	request.Header.Set("Content-Type", "application/vnd.api+json")
+	return c.do(OperationCreate, request)
+}
+
+// CreateAccountWithContext is CreateAccount bound to ctx
+func (c *Client) CreateAccountWithContext(ctx context.Context, account *Account) (*http.Response, error) {
+
+	jsonBytes, err := Marshaller(account)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := c.newRequestWithContext(ctx, http.MethodPost, accountsPath, bytes.NewReader(jsonBytes))
 	if err != nil {
 		return nil, err
 	}
 
 	request.Header.Set("Content-Type", "application/vnd.api+json")
-	client := &http.Client{}
-	return client.Do(request)
+	return c.do(OperationCreate, request)
+}
+
+// Create creates the given account and returns the account stored by the form3
+// api. The account is checked with Validate and the AccountValidators of the
+// Client first and not sent when invalid.
+func (c *Client) Create(ctx context.Context, account *AccountData) (*AccountData, error) {
+
+	if err := account.Validate(c.validators...); err != nil {
+		return nil, err
+	}
+
+	response, err := c.CreateAccountWithContext(ctx, &Account{Data: *account})
+	if err != nil {
+		return nil, err
+	}
+
+	created := &Account{}
+	if err := decodeResponse(response, created); err != nil {
+		return nil, err
+	}
+	return &created.Data, nil
+}
+
+// CreateOrGet creates the given account like Create, but can safely be
+// called again for the same account, e.g. after a timeout left it unknown
+// whether the account was stored. When the form3 api refuses the create with
+// a conflict and an account with the same id exists, that account is fetched
+// and compared attribute by attribute with the requested one: it is returned
+// when they match, otherwise an *AccountMismatchError lists the differences.
+// An attribute that is absent from the requested account is not compared.
+func (c *Client) CreateOrGet(ctx context.Context, account *AccountData) (*AccountData, error) {
+
+	created, err := c.Create(ctx, account)
+	if !IsConflict(err) {
+		return created, err
+	}
+
+	existing, fetchErr := c.Fetch(ctx, account.ID)
+	if IsNotFound(fetchErr) {
+		// the conflict is not about the id, e.g. a duplicate account number
+		return nil, err
+	}
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	if mismatches := diffAccounts(account, existing); len(mismatches) > 0 {
+		return nil, &AccountMismatchError{AccountID: account.ID, Mismatches: mismatches}
+	}
+	return existing, nil
 }
 
 // UnmarshallCreateAccountResponse returns the  Account struct from the http.Response
 func UnmarshallCreateAccountResponse(response *http.Response) (*Account, error) {
+	defer drainAndClose(response.Body)
 
 	byteArr, err := IOResponseBodyReader(response.Body)
 	if err != nil {
@@ -96,7 +139,5 @@ func UnmarshallCreateAccountResponse(response *http.Response) (*Account, error)
 		return nil, err
 	}
 
-	fmt.Println("Created account", createdAccount)
-
 	return createdAccount, nil
 }
@@ -1,27 +1,97 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
 // DeleteAccount calls the form3 api with the specified accountID and version
+//
+// Deprecated: construct a Client with NewClient and use Client.DeleteAccount
 func DeleteAccount(host, accountID string, version int) (*http.Response, error) {
+	return hostClient(host).DeleteAccount(accountID, version)
+}
 
-	fmt.Println("in DeleteAccount, id", accountID, "version", version)
-
-	uri := "/v1/organisation/accounts/"
+// DeleteAccount calls the form3 api with the specified accountID and version
+func (c *Client) DeleteAccount(accountID string, version int) (*http.Response, error) {
 
-	// Create client
-	client := &http.Client{}
+	uri := accountsPath + "/"
 
 	// Create request
-	req, err := RequestCreator("DELETE", host+uri+accountID+"?version="+fmt.Sprint(version), nil)
+	req, err := c.newRequest("DELETE", uri+accountID+"?version="+fmt.Sprint(version), nil)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
 	// Fetch Request
-	return client.Do(req)
+	return c.do(OperationDelete, req)
+}
+
+// DeleteAccountWithContext is DeleteAccount bound to ctx
+func (c *Client) DeleteAccountWithContext(ctx context.Context, accountID string, version int) (*http.Response, error) {
+
+	request, err := c.newRequestWithContext(ctx, http.MethodDelete, accountsPath+"/"+accountID+"?version="+fmt.Sprint(version), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(OperationDelete, request)
+}
+
+// Delete deletes the account with the specified accountID and version. When
+// the account is at another version a *VersionConflictError is returned.
+func (c *Client) Delete(ctx context.Context, accountID string, version int) error {
+
+	response, err := c.DeleteAccountWithContext(ctx, accountID, version)
+	if err != nil {
+		return err
+	}
+
+	return versionConflict(decodeResponse(response, nil))
+}
+
+// DefaultDeleteLatestAttempts is the number of fetch and delete rounds of
+// DeleteLatest unless DeleteLatestOptions say otherwise
+const DefaultDeleteLatestAttempts = 3
+
+// DeleteLatestOptions configures DeleteLatest
+type DeleteLatestOptions struct {
+	// Attempts is the maximum number of fetch and delete rounds
+	Attempts int
+	// IgnoreNotFound treats an account that does not exist as already deleted
+	IgnoreNotFound bool
+}
+
+// DeleteLatest deletes the account with the specified accountID whatever its
+// version. It fetches the account and deletes it with the fetched version.
+// When the version moved in between, the delete is refused with a version
+// conflict and the round is repeated, up to Attempts times, after which the
+// last *VersionConflictError is returned. With nil options the defaults are
+// used and an account that does not exist is an error.
+func (c *Client) DeleteLatest(ctx context.Context, accountID string, options *DeleteLatestOptions) error {
+
+	if options == nil {
+		options = &DeleteLatestOptions{}
+	}
+	attempts := options.Attempts
+	if attempts < 1 {
+		attempts = DefaultDeleteLatestAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		var account *AccountData
+		account, err = c.Fetch(ctx, accountID)
+		if err == nil {
+			err = c.Delete(ctx, accountID, account.Version)
+		}
+		if IsNotFound(err) && options.IgnoreNotFound {
+			return nil
+		}
+		if !IsVersionConflict(err) {
+			return err
+		}
+	}
+	return err
 }
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize limits how much of an error response body is read
+const maxErrorBodySize = 64 << 10
+
+// APIError is returned by the typed operations when the form3 api responds
+// with a non 2xx status
+type APIError struct {
+	StatusCode   int
+	ErrorMessage string
+	ErrorCode    string
+	RequestID    string
+	Method       string
+	Path         string
+	// Attempts is the number of attempts made before the error was returned
+	Attempts int
+}
+
+// errorBody is the json body of a form3 error response
+type errorBody struct {
+	ErrorMessage string `json:"error_message"`
+	ErrorCode    string `json:"error_code"`
+}
+
+// newAPIError creates an APIError from a non 2xx response. The error_message
+// and error_code of the body are used when the body is form3 error json,
+// otherwise the raw body becomes the error message.
+func newAPIError(response *http.Response) *APIError {
+
+	apiError := &APIError{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get("X-Request-Id"),
+	}
+	if response.Request != nil {
+		apiError.Method = response.Request.Method
+		apiError.Path = response.Request.URL.Path
+		apiError.Attempts = attemptNumber(response.Request.Context())
+	}
+
+	byteArr, err := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	if err != nil || len(byteArr) == 0 {
+		return apiError
+	}
+
+	body := &errorBody{}
+	if err := json.Unmarshal(byteArr, body); err == nil {
+		apiError.ErrorMessage = body.ErrorMessage
+		apiError.ErrorCode = body.ErrorCode
+	} else {
+		apiError.ErrorMessage = strings.TrimSpace(string(byteArr))
+	}
+	return apiError
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: form3 api responded with status %d", e.Method, e.Path, e.StatusCode)
+	if e.ErrorCode != "" {
+		msg = msg + " (error code " + e.ErrorCode + ")"
+	}
+	if e.ErrorMessage != "" {
+		msg = msg + ": " + e.ErrorMessage
+	}
+	if e.RequestID != "" {
+		msg = msg + " [request id " + e.RequestID + "]"
+	}
+	if e.Attempts > 1 {
+		msg = msg + fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return msg
+}
+
+// RateLimitError is returned when the form3 api rate limited a request and the
+// Client gave up waiting, either because the wait the api asked for would
+// exceed the deadline of the context or MaxRetryAfter, or because the request
+// was rate limited more than MaxRateLimitWaits times
+type RateLimitError struct {
+	*APIError
+	// RetryAfter is the wait the api asked for, 0 when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s: %s", e.RetryAfter, e.APIError.Error())
+}
+
+// Unwrap returns the APIError of the rate limited response
+func (e *RateLimitError) Unwrap() error {
+	return e.APIError
+}
+
+// VersionConflictError is returned by UpdateAccount and Delete when the form3
+// api refuses the request because the account is no longer at the version
+// sent, i.e. it was changed in the meantime
+type VersionConflictError struct {
+	*APIError
+}
+
+func (e *VersionConflictError) Error() string {
+	return "version conflict: " + e.APIError.Error()
+}
+
+// Unwrap returns the APIError of the conflicting response
+func (e *VersionConflictError) Unwrap() error {
+	return e.APIError
+}
+
+// versionConflict turns an APIError with status 409 into a VersionConflictError
+func versionConflict(err error) error {
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusConflict {
+		return &VersionConflictError{APIError: apiError}
+	}
+	return err
+}
+
+// AccountMismatchError is returned by CreateOrGet when an account with the
+// requested id exists but differs from the requested account
+type AccountMismatchError struct {
+	AccountID  string
+	Mismatches []Mismatch
+}
+
+// Mismatch is a field whose requested and existing values differ
+type Mismatch struct {
+	// Field is the json path of the field, e.g. attributes.bic
+	Field     string
+	Requested string
+	Existing  string
+}
+
+func (e *AccountMismatchError) Error() string {
+	msg := "account " + e.AccountID + " exists with other values:"
+	for i, mismatch := range e.Mismatches {
+		if i > 0 {
+			msg = msg + ","
+		}
+		msg = msg + fmt.Sprintf(" %s is %s, requested %s", mismatch.Field, mismatch.Existing, mismatch.Requested)
+	}
+	return msg
+}
+
+// IsRateLimited reports whether err is a RateLimitError
+func IsRateLimited(err error) bool {
+	var rateLimitError *RateLimitError
+	return errors.As(err, &rateLimitError)
+}
+
+// IsVersionConflict reports whether err is a VersionConflictError
+func IsVersionConflict(err error) bool {
+	var versionConflictError *VersionConflictError
+	return errors.As(err, &versionConflictError)
+}
+
+// IsNotFound reports whether err is an APIError with status 404
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError with status 409, e.g. a
+// duplicate account or a version mismatch
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsValidationError reports whether the request data was rejected, either by
+// Validate before sending (ValidationErrors) or by the form3 api with an
+// APIError with status 400
+func IsValidationError(err error) bool {
+	var validationErrors ValidationErrors
+	return errors.As(err, &validationErrors) || hasStatus(err, http.StatusBadRequest)
+}
+
+// IsRetryable reports whether err is an APIError with a status that is worth
+// retrying, i.e. 429 or a 500, 502, 503 or 504 server error
+func IsRetryable(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout)
+}
+
+// hasStatus reports whether err is an APIError with one of the given statuses
+func hasStatus(err error, statusCodes ...int) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	for _, statusCode := range statusCodes {
+		if apiError.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
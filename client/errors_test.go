@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate_whenForm3ApiRejectsAccount_shouldReturnAPIError(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "5a0c53d6-1b5d-4b8e-9f0e-2d7a1a3b0c11")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error_message":"validation failure: bic in body should match '^([A-Z]{6}[A-Z0-9]{2}|[A-Z]{6}[A-Z0-9]{5})$'","error_code":"a6a1a5a6-1f2c-4e5e-b7b8-7f2b5e0c1d2e"}`)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	_, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, http.StatusBadRequest, apiError.StatusCode)
+	assert.EqualValues(t, "a6a1a5a6-1f2c-4e5e-b7b8-7f2b5e0c1d2e", apiError.ErrorCode)
+	assert.Contains(t, apiError.ErrorMessage, "validation failure: bic")
+	assert.EqualValues(t, "5a0c53d6-1b5d-4b8e-9f0e-2d7a1a3b0c11", apiError.RequestID)
+	assert.EqualValues(t, http.MethodPost, apiError.Method)
+	assert.EqualValues(t, "/v1/organisation/accounts", apiError.Path)
+	assert.Contains(t, err.Error(), "POST /v1/organisation/accounts: form3 api responded with status 400")
+	assert.True(t, IsValidationError(err))
+	assert.False(t, IsRetryable(err))
+}
+
+func TestFetch_whenErrorBodyIsNotJSON_shouldUseBodyAsErrorMessage(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "upstream connect error\n")
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	_, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, "upstream connect error", apiError.ErrorMessage)
+	assert.True(t, IsRetryable(err))
+}
+
+func TestAPIError_helpers(t *testing.T) {
+	notFound := &APIError{StatusCode: http.StatusNotFound}
+	conflict := fmt.Errorf("delete failed: %w", &APIError{StatusCode: http.StatusConflict})
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable}
+	other := errors.New("connection refused")
+
+	assert.True(t, IsNotFound(notFound))
+	assert.False(t, IsNotFound(conflict))
+	assert.True(t, IsConflict(conflict))
+	assert.True(t, IsRetryable(unavailable))
+	assert.False(t, IsRetryable(notFound))
+	assert.False(t, IsNotFound(other))
+	assert.False(t, IsRetryable(other))
+	assert.False(t, IsConflict(nil))
+
+	staleVersion := versionConflict(conflict)
+	assert.True(t, IsVersionConflict(staleVersion))
+	assert.True(t, IsConflict(staleVersion))
+	assert.False(t, IsVersionConflict(conflict))
+	assert.False(t, IsVersionConflict(notFound))
+	assert.Equal(t, notFound, versionConflict(notFound))
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// DefaultGatherConcurrency is the number of pages GatherAccountsConcurrently
+// fetches at the same time unless GatherOptions say otherwise
+const DefaultGatherConcurrency = 4
+
+// DefaultGatherMaxPages is the number of pages above which
+// GatherAccountsConcurrently does not trust the last page number told by the
+// form3 api unless GatherOptions say otherwise
+const DefaultGatherMaxPages = 10000
+
+// GatherOptions configures GatherAccountsConcurrently
+type GatherOptions struct {
+	// PageSize is the size of the requested pages, 0 for the default of the form3 api
+	PageSize int
+	// Concurrency is the maximum number of pages fetched at the same time
+	Concurrency int
+	// MaxPages is the largest number of pages fetched concurrently. When the
+	// last page number of the form3 api exceeds it, the pages are fetched one
+	// by one following links.next instead.
+	MaxPages int
+	// Filter, when set, restricts the gathered accounts to the matching ones
+	Filter *AccountFilter
+	// Progress is called, when set, after every fetched page with the number
+	// of pages fetched so far and the total number of pages, which is 0 when
+	// the form3 api did not tell the last page. It is never called concurrently.
+	Progress func(fetchedPages, totalPages int)
+}
+
+// GatherAccountsConcurrently gets the list of all existing accounts in db.
+// Once the last page number is known from the links.last of the first page,
+// the remaining pages are fetched concurrently. The pages are reassembled in
+// order and accounts that appear on two pages, because accounts were created
+// while paging, are kept once. When the last page number cannot be told, or
+// exceeds GatherOptions.MaxPages, the pages are fetched one by one following
+// links.next.
+//
+// When a page fails the other requests are cancelled, and the accounts of the
+// pages before the failed one are returned together with the error.
+func (c *Client) GatherAccountsConcurrently(ctx context.Context, options *GatherOptions) ([]AccountData, error) {
+
+	if options == nil {
+		options = &GatherOptions{}
+	}
+	g := &gatherer{client: c, progress: options.Progress, seen: map[string]bool{}, accounts: make([]AccountData, 0)}
+
+	firstPath := (&ListOptions{PageNumber: 0, PageSize: options.PageSize, Filter: options.Filter}).path()
+	first, err := c.listPage(ctx, firstPath)
+	if err != nil {
+		return g.accounts, err
+	}
+
+	lastPage, last, ok, err := c.lastPage(ctx, first.Links)
+	if err != nil {
+		g.add(first.Data)
+		return g.accounts, err
+	}
+	maxPages := options.MaxPages
+	if maxPages < 1 {
+		maxPages = DefaultGatherMaxPages
+	}
+	// the number comes from the api: it sizes the pages, so it must be sane
+	if !ok || lastPage < 0 || lastPage >= maxPages {
+		return g.sequentially(ctx, first, firstPath)
+	}
+
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultGatherConcurrency
+	}
+	return g.concurrently(ctx, first, last, firstPath, lastPage, concurrency)
+}
+
+// gatherer holds the state of GatherAccountsConcurrently
+type gatherer struct {
+	client   *Client
+	progress func(fetchedPages, totalPages int)
+
+	mu           sync.Mutex
+	fetchedPages int
+
+	seen     map[string]bool
+	accounts []AccountData
+}
+
+// sequentially gathers the accounts by following the next links from the first page
+func (g *gatherer) sequentially(ctx context.Context, page *AccountList, path string) ([]AccountData, error) {
+
+	for {
+		g.add(page.Data)
+		g.pageFetched(0)
+
+		next, err := g.client.nextPath(page.Links, len(page.Data), path)
+		if err != nil || next == "" {
+			return g.accounts, err
+		}
+
+		page, err = g.client.listPage(ctx, next)
+		if err != nil {
+			return g.accounts, err
+		}
+		path = next
+	}
+}
+
+// concurrently gathers the accounts of pages 1 to lastPage with concurrency
+// workers, requesting the pages with the parameters of the first page. The
+// last page is not requested again when it was already fetched.
+func (g *gatherer) concurrently(ctx context.Context, first, last *AccountList, firstPath string, lastPage int, concurrency int) ([]AccountData, error) {
+
+	totalPages := lastPage + 1
+	pages := make([][]AccountData, totalPages)
+	fetched := make([]bool, totalPages)
+	pages[0], fetched[0] = first.Data, true
+	g.pageFetched(totalPages)
+	if last != nil && lastPage > 0 {
+		pages[lastPage], fetched[lastPage] = last.Data, true
+		g.pageFetched(totalPages)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	var errOnce sync.Once
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < lastPage; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range jobs {
+				page, err := g.client.listPage(ctx, pagePath(firstPath, number))
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				pages[number], fetched[number] = page.Data, true
+				g.pageFetched(totalPages)
+			}
+		}()
+	}
+
+feed:
+	for number := 1; number <= lastPage; number++ {
+		if fetched[number] {
+			continue
+		}
+		select {
+		case jobs <- number:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for number, page := range pages {
+		if !fetched[number] {
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			return g.accounts, firstErr
+		}
+		g.add(page)
+	}
+	return g.accounts, nil
+}
+
+// add appends the accounts that were not seen before
+func (g *gatherer) add(accounts []AccountData) {
+	for _, account := range accounts {
+		if g.seen[account.ID] {
+			continue
+		}
+		g.seen[account.ID] = true
+		g.accounts = append(g.accounts, account)
+	}
+}
+
+// pageFetched counts a fetched page and reports the progress
+func (g *gatherer) pageFetched(totalPages int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.fetchedPages++
+	if g.progress != nil {
+		g.progress(g.fetchedPages, totalPages)
+	}
+}
+
+// lastPage returns the number of the last page, told by the links.last of
+// the first page. The form3 api links to it with page[number]=last rather
+// than with its number: that page is then fetched, and returned, for the
+// number of its links.self, or the one after its links.prev. ok is false when
+// the number cannot be told.
+func (c *Client) lastPage(ctx context.Context, links Links) (number int, last *AccountList, ok bool, err error) {
+
+	if links.Last == "" {
+		return 0, nil, false, nil
+	}
+	if number, ok := c.pageNumber(links.Last); ok {
+		return number, nil, true, nil
+	}
+
+	path, err := c.linkPath(links.Last)
+	if err != nil {
+		return 0, nil, false, nil
+	}
+	if last, err = c.listPage(ctx, path); err != nil {
+		return 0, nil, false, err
+	}
+	if number, ok := c.pageNumber(last.Links.Self); ok {
+		return number, last, true, nil
+	}
+	if number, ok := c.pageNumber(last.Links.Prev); ok {
+		return number + 1, last, true, nil
+	}
+	return 0, nil, false, nil
+}
+
+// pageNumber returns the page[number] of a pagination link, if it is numeric
+func (c *Client) pageNumber(link string) (int, bool) {
+
+	if link == "" {
+		return 0, false
+	}
+	path, err := c.linkPath(link)
+	if err != nil {
+		return 0, false
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(u.Query().Get("page[number]"))
+	if err != nil || number < 0 {
+		return 0, false
+	}
+	return number, true
+}
+
+// pagePath returns the path of the page with the given number, i.e. path,
+// which keeps the page size, the filter and any other parameter, with its
+// page[number] replaced
+func pagePath(path string, number int) string {
+	u, _ := url.Parse(path)
+	query := u.Query()
+	query.Set("page[number]", strconv.Itoa(number))
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
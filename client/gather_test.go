@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherAccountsConcurrently_shouldReassemblePagesInOrder(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(3), testAccounts(3), testAccounts(3), testAccounts(3), testAccounts(1)}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	var progress [][2]int
+	options := &GatherOptions{PageSize: 3, Concurrency: 2, Progress: func(fetchedPages, totalPages int) {
+		progress = append(progress, [2]int{fetchedPages, totalPages})
+	}}
+
+	// test
+	accounts, err := c.GatherAccountsConcurrently(context.Background(), options)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 13, len(accounts))
+	i := 0
+	for _, page := range pages {
+		for _, account := range page {
+			assert.EqualValues(t, account.ID, accounts[i].ID)
+			i++
+		}
+	}
+	assert.EqualValues(t, 2, maxInFlight)
+	assert.EqualValues(t, 5, len(progress))
+	assert.EqualValues(t, [2]int{1, 5}, progress[0])
+	assert.EqualValues(t, [2]int{5, 5}, progress[4])
+}
+
+func TestGatherAccountsConcurrently_shouldRemoveDuplicates(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	first := testAccounts(2)
+	second := append([]AccountData{first[1]}, testAccounts(1)...)
+	pages := [][]AccountData{first, second}
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.GatherAccountsConcurrently(context.Background(), &GatherOptions{PageSize: 2})
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, len(accounts))
+	assert.EqualValues(t, first[0].ID, accounts[0].ID)
+	assert.EqualValues(t, first[1].ID, accounts[1].ID)
+	assert.EqualValues(t, second[1].ID, accounts[2].ID)
+}
+
+func TestGatherAccountsConcurrently_whenPageFails_shouldReturnPagesBeforeItAndError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2), testAccounts(2)}
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page[number]") == "2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.GatherAccountsConcurrently(context.Background(), &GatherOptions{PageSize: 2, Concurrency: 1})
+
+	// validate
+	assert.True(t, IsNotFound(err))
+	assert.EqualValues(t, 4, len(accounts))
+	assert.EqualValues(t, pages[1][1].ID, accounts[3].ID)
+}
+
+func TestGatherAccountsConcurrently_withSymbolicLinks_shouldResolveTheLastPage(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2), testAccounts(1)}
+	symbolic := func(name string) string {
+		return "/v1/organisation/accounts?page%5Bnumber%5D=" + name + "&page%5Bsize%5D=2"
+	}
+
+	// the form3 api links to the first and last pages by name; the last page
+	// either tells its number in links.self or only through links.prev
+	for _, numberedSelf := range []bool{true, false} {
+		var mu sync.Mutex
+		var requested []string
+		server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested = append(requested, r.URL.Query().Get("page[number]"))
+			mu.Unlock()
+
+			if r.URL.Query().Get("page[number]") == "last" {
+				r.URL.RawQuery = "page%5Bnumber%5D=3&page%5Bsize%5D=2"
+			}
+			page := testPage(r, pages)
+			page.Links.First, page.Links.Last = symbolic("first"), symbolic("last")
+			if page.Links.Next == "" && !numberedSelf {
+				page.Links.Self = symbolic("last")
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(page)
+		})
+		c, _ := NewClient(server.URL)
+
+		var totals []int
+		options := &GatherOptions{PageSize: 2, Progress: func(fetchedPages, totalPages int) {
+			totals = append(totals, totalPages)
+		}}
+
+		// test
+		accounts, err := c.GatherAccountsConcurrently(context.Background(), options)
+
+		// validate
+		assert.Nil(t, err)
+		assert.EqualValues(t, 7, len(accounts))
+		assert.EqualValues(t, pages[3][0].ID, accounts[6].ID)
+		assert.EqualValues(t, []int{4, 4, 4, 4}, totals)
+		assert.EqualValues(t, 4, len(requested))
+		assert.EqualValues(t, []string{"0", "last"}, requested[:2])
+		server.Close()
+	}
+}
+
+func TestGatherAccountsConcurrently_withHugeLastPage_shouldFollowNextLinks(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(1)}
+
+	for _, number := range []string{"9223372036854775807", "1000000000", "3"} {
+		requests := 0
+		server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			page := testPage(r, pages)
+			page.Links.Last = "/v1/organisation/accounts?page%5Bnumber%5D=" + number + "&page%5Bsize%5D=2"
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(page)
+		})
+		c, _ := NewClient(server.URL)
+
+		var totals []int
+		options := &GatherOptions{PageSize: 2, MaxPages: 3, Progress: func(fetchedPages, totalPages int) {
+			totals = append(totals, totalPages)
+		}}
+
+		// test
+		accounts, err := c.GatherAccountsConcurrently(context.Background(), options)
+
+		// validate
+		assert.Nil(t, err, number)
+		assert.EqualValues(t, 5, len(accounts), number)
+		assert.EqualValues(t, 3, requests, number)
+		assert.EqualValues(t, []int{0, 0, 0}, totals, number)
+		server.Close()
+	}
+}
+
+func TestGatherAccountsConcurrently_withoutLastLink_shouldFollowNextLinks(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(1)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := testPage(r, pages)
+		page.Links.Last = ""
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(page)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	var totals []int
+	options := &GatherOptions{PageSize: 2, Progress: func(fetchedPages, totalPages int) {
+		totals = append(totals, totalPages)
+	}}
+
+	// test
+	accounts, err := c.GatherAccountsConcurrently(context.Background(), options)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 5, len(accounts))
+	assert.EqualValues(t, 3, requests)
+	assert.EqualValues(t, []int{0, 0, 0}, totals)
+}
+
+func TestGatherAccountsConcurrently_withFilter_shouldFilterEveryPage(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2)}
+
+	var mu sync.Mutex
+	var filters []string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		filters = append(filters, r.URL.Query().Get("filter[bank_id]"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.GatherAccountsConcurrently(context.Background(),
+		&GatherOptions{PageSize: 2, Filter: &AccountFilter{BankID: "400300"}})
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 6, len(accounts))
+	assert.EqualValues(t, []string{"400300", "400300", "400300"}, filters)
+}
+
+func TestPagePath(t *testing.T) {
+	path := pagePath("/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=0&page%5Bsize%5D=100", 3)
+
+	assert.EqualValues(t, "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=3&page%5Bsize%5D=100", path)
+}
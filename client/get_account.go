@@ -1,72 +1,68 @@
 package client
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-	"time"
 )
 
-// Gattributes ...
-type Gattributes struct {
-	BankID        string `json:"bank_id"`
-	BankIDCode    string `json:"bank_id_code"`
-	BaseCurrency  string `json:"base_currency"`
-	Bic           string `json:"bic"`
-	Country       string `json:"country"`
-	AccountNumber string `json:"account_number"`
-	Iban          string `json:"iban"`
-	Status        string `json:"status"`
+// GetAccount calls the form3 api with the specified accountID
+//
+// Deprecated: construct a Client with NewClient and use Client.GetAccount
+func GetAccount(host, accountID string) (*http.Response, error) {
+	return hostClient(host).GetAccount(accountID)
 }
 
-// Gdata ...
-type Gdata struct {
-	Gattributes    Gattributes `json:"attributes"`
-	CreatedOn      time.Time   `json:"created_on"`
-	ID             string      `json:"id"`
-	ModifiedOn     time.Time   `json:"modified_on"`
-	OrganisationID string      `json:"organisation_id"`
-	Type           string      `json:"type"`
-	Version        int         `json:"version"`
-}
+// GetAccount calls the form3 api with the specified accountID
+func (c *Client) GetAccount(accountID string) (*http.Response, error) {
+
+	request, err := c.newRequest(http.MethodGet, accountsPath+"/"+accountID, nil)
+	if err != nil {
+		return nil, err
+	}
 
-// GetAccountResponse  ...
-type GetAccountResponse struct {
-	Gdata Gdata `json:"data"`
+	return c.do(OperationFetch, request)
 }
 
-// GetAccount calls the form3 api with the specified accountID
-func GetAccount(host, accountID string) (*http.Response, error) {
-	fmt.Println("in GetAccount, id", accountID)
+// GetAccountWithContext is GetAccount bound to ctx
+func (c *Client) GetAccountWithContext(ctx context.Context, accountID string) (*http.Response, error) {
 
-	uri := "/v1/organisation/accounts/" + accountID
+	request, err := c.newRequestWithContext(ctx, http.MethodGet, accountsPath+"/"+accountID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(OperationFetch, request)
+}
 
-	request, err := RequestCreator(http.MethodGet, host+uri, nil)
+// Fetch gets the account with the specified accountID
+func (c *Client) Fetch(ctx context.Context, accountID string) (*AccountData, error) {
+
+	response, err := c.GetAccountWithContext(ctx, accountID)
 	if err != nil {
-		fmt.Print(err)
 		return nil, err
 	}
 
-	client := &http.Client{}
-	return client.Do(request)
+	account := &Account{}
+	if err := decodeResponse(response, account); err != nil {
+		return nil, err
+	}
+	return &account.Data, nil
 }
 
-// UnmarshallGetAccountResponse returns the  GetAccountResponse struct from the http.Response
-func UnmarshallGetAccountResponse(response *http.Response) (account *GetAccountResponse, err error) {
+// UnmarshallGetAccountResponse returns the  Account struct from the http.Response
+func UnmarshallGetAccountResponse(response *http.Response) (account *Account, err error) {
+	defer drainAndClose(response.Body)
 
 	byteArr, err := IOResponseBodyReader(response.Body)
 	if err != nil {
-		fmt.Print(err)
 		return nil, err
 	}
 
-	account = &GetAccountResponse{}
+	account = &Account{}
 	err = Unmarshaller(byteArr, &account)
 	if err != nil {
-		fmt.Print(err)
 		return nil, err
 	}
 
-	fmt.Println("Got account", account)
-
 	return account, nil
 }
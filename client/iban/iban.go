@@ -0,0 +1,325 @@
+// Package iban parses, validates, formats and generates International Bank
+// Account Numbers (ISO 13616).
+//
+// An IBAN is a country code, two check digits and a country specific Basic
+// Bank Account Number (BBAN). Validate checks the length and the structure of
+// the BBAN of the country and the mod-97 check digits. For the countries the
+// form3 api supports, BankID extracts the bank id of an IBAN and Generate
+// derives an IBAN from the bank attributes of an account.
+package iban
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedCountry is returned for a country without IBANs
+	ErrUnsupportedCountry = errors.New("country has no iban")
+	// ErrInvalidLength is returned for an IBAN that is not of the length of its country
+	ErrInvalidLength = errors.New("invalid iban length")
+	// ErrInvalidBBAN is returned for an IBAN whose BBAN has not the structure of its country
+	ErrInvalidBBAN = errors.New("invalid iban bban")
+	// ErrInvalidChecksum is returned for an IBAN whose check digits are wrong
+	ErrInvalidChecksum = errors.New("invalid iban check digits")
+)
+
+// spec is the IBAN structure of a country
+type spec struct {
+	length int
+	bban   *regexp.Regexp
+	// bankID is the position of the form3 bank id in the BBAN, none when empty
+	bankID [2]int
+	// generate builds the BBAN of a BankAccount, nil when not supported
+	generate func(account BankAccount) (string, error)
+}
+
+// alphanumeric is the BBAN structure of the countries without a detailed spec
+var alphanumeric = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// specs are the IBAN structures of the countries supported by the form3 api
+var specs = map[string]spec{
+	"BE": {16, regexp.MustCompile(`^[0-9]{12}$`), [2]int{0, 3}, belgianBBAN},
+	"CH": {21, regexp.MustCompile(`^[0-9]{5}[A-Z0-9]{12}$`), [2]int{0, 5}, concatBBAN(5, 12)},
+	"DE": {22, regexp.MustCompile(`^[0-9]{18}$`), [2]int{0, 8}, concatBBAN(8, 10)},
+	"ES": {24, regexp.MustCompile(`^[0-9]{20}$`), [2]int{0, 8}, spanishBBAN},
+	"FR": {27, regexp.MustCompile(`^[0-9]{10}[A-Z0-9]{11}[0-9]{2}$`), [2]int{0, 10}, frenchBBAN},
+	"GB": {22, regexp.MustCompile(`^[A-Z]{4}[0-9]{14}$`), [2]int{4, 10}, britishBBAN},
+	"GR": {27, regexp.MustCompile(`^[0-9]{7}[A-Z0-9]{16}$`), [2]int{0, 7}, concatBBAN(7, 16)},
+	"IT": {27, regexp.MustCompile(`^[A-Z][0-9]{10}[A-Z0-9]{12}$`), [2]int{1, 11}, italianBBAN},
+	"LU": {20, regexp.MustCompile(`^[0-9]{3}[A-Z0-9]{13}$`), [2]int{0, 3}, concatBBAN(3, 13)},
+	"NL": {18, regexp.MustCompile(`^[A-Z]{4}[0-9]{10}$`), [2]int{}, dutchBBAN},
+	"PL": {28, regexp.MustCompile(`^[0-9]{24}$`), [2]int{0, 8}, concatBBAN(8, 16)},
+	"PT": {25, regexp.MustCompile(`^[0-9]{21}$`), [2]int{0, 8}, portugueseBBAN},
+}
+
+// lengths are the IBAN lengths of the other countries that use IBANs
+var lengths = map[string]int{
+	"AD": 24, "AE": 23, "AT": 20, "AZ": 28, "BA": 20, "BG": 22, "BH": 22, "BR": 29,
+	"CR": 22, "CY": 28, "CZ": 24, "DK": 18, "DO": 28, "EE": 20, "FI": 18, "FO": 18,
+	"GE": 22, "GI": 23, "GL": 18, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23,
+	"IS": 26, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LI": 21, "LT": 20, "LV": 21,
+	"MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NO": 15,
+	"PK": 24, "PS": 29, "QA": 29, "RO": 24, "RS": 22, "SA": 24, "SE": 24, "SI": 19,
+	"SK": 24, "SM": 27, "TN": 24, "TR": 26, "UA": 29, "VG": 24, "XK": 20,
+}
+
+// specOf returns the spec of a country
+func specOf(country string) (spec, bool) {
+	if s, ok := specs[country]; ok {
+		return s, true
+	}
+	if length, ok := lengths[country]; ok {
+		return spec{length: length, bban: alphanumeric}, true
+	}
+	return spec{}, false
+}
+
+// Electronic returns the electronic form of an IBAN: upper case, without spaces
+func Electronic(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// Print returns the print form of an IBAN: upper case, in groups of four
+// characters separated by a space
+func Print(iban string) string {
+	electronic := Electronic(iban)
+	groups := make([]string, 0, len(electronic)/4+1)
+	for len(electronic) > 4 {
+		groups = append(groups, electronic[:4])
+		electronic = electronic[4:]
+	}
+	groups = append(groups, electronic)
+	return strings.Join(groups, " ")
+}
+
+// Validate checks an IBAN, in electronic or print form: its country, its
+// length, the structure of its BBAN and its check digits
+func Validate(iban string) error {
+
+	electronic := Electronic(iban)
+	if len(electronic) < 4 {
+		return fmt.Errorf("%w: %q", ErrInvalidLength, iban)
+	}
+	country := electronic[:2]
+	s, ok := specOf(country)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCountry, country)
+	}
+	if len(electronic) != s.length {
+		return fmt.Errorf("%w: %q has %d characters, %s ibans have %d", ErrInvalidLength, iban, len(electronic), country, s.length)
+	}
+	if !s.bban.MatchString(electronic[4:]) {
+		return fmt.Errorf("%w: %q", ErrInvalidBBAN, iban)
+	}
+	if _, err := strconv.Atoi(electronic[2:4]); err != nil || mod97(electronic[4:]+electronic[:4]) != 1 {
+		return fmt.Errorf("%w: %q", ErrInvalidChecksum, iban)
+	}
+	return nil
+}
+
+// Country returns the country code of an IBAN
+func Country(iban string) string {
+	electronic := Electronic(iban)
+	if len(electronic) < 2 {
+		return ""
+	}
+	return electronic[:2]
+}
+
+// BankID returns the part of a valid IBAN that is the bank_id of a form3
+// account, e.g. the sort code of a GB IBAN. It returns false when the country
+// has no bank id in its IBANs or is not supported.
+func BankID(iban string) (string, bool) {
+	electronic := Electronic(iban)
+	s, ok := specs[Country(electronic)]
+	if !ok || s.bankID[1] == 0 || len(electronic) != s.length {
+		return "", false
+	}
+	return electronic[4+s.bankID[0] : 4+s.bankID[1]], true
+}
+
+// BankAccount holds the bank attributes of an account an IBAN is generated from
+type BankAccount struct {
+	Country string
+	// Bic is needed for GB and NL, whose IBANs start with the bank code of the BIC
+	Bic           string
+	BankID        string
+	AccountNumber string
+}
+
+// Generate derives the IBAN, in electronic form, of a bank account of a
+// country supported by the form3 api. National check digits, e.g. the RIB key
+// of FR or the CIN of IT, are computed.
+func Generate(account BankAccount) (string, error) {
+
+	s, ok := specs[account.Country]
+	if !ok || s.generate == nil {
+		return "", fmt.Errorf("%w: cannot generate for %q", ErrUnsupportedCountry, account.Country)
+	}
+	bban, err := s.generate(account)
+	if err != nil {
+		return "", err
+	}
+	if !s.bban.MatchString(bban) {
+		return "", fmt.Errorf("%w: bank id %q and account number %q do not form a %s bban", ErrInvalidBBAN,
+			account.BankID, account.AccountNumber, account.Country)
+	}
+	check := 98 - mod97(bban+account.Country+"00")
+	return fmt.Sprintf("%s%02d%s", account.Country, check, bban), nil
+}
+
+// mod97 returns the remainder of the division by 97 of s, whose letters count
+// as the numbers 10 to 35
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
+
+// leftPad pads s with zeros to length n, or returns "" when s is longer
+func leftPad(s string, n int) string {
+	if len(s) > n {
+		return ""
+	}
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// concatBBAN returns a generator of the BBANs that are the bank id and the
+// account number, each padded with zeros to its length
+func concatBBAN(bankIDLength, accountLength int) func(BankAccount) (string, error) {
+	return func(account BankAccount) (string, error) {
+		return leftPad(account.BankID, bankIDLength) + leftPad(strings.ToUpper(account.AccountNumber), accountLength), nil
+	}
+}
+
+// bankCode returns the four letter bank code of a BIC
+func bankCode(account BankAccount) (string, error) {
+	if len(account.Bic) < 4 {
+		return "", fmt.Errorf("%w: a %s iban needs the bank code of the bic", ErrInvalidBBAN, account.Country)
+	}
+	return strings.ToUpper(account.Bic[:4]), nil
+}
+
+// britishBBAN is the bank code of the BIC, the sort code and the account number
+func britishBBAN(account BankAccount) (string, error) {
+	code, err := bankCode(account)
+	if err != nil {
+		return "", err
+	}
+	return code + account.BankID + leftPad(account.AccountNumber, 8), nil
+}
+
+// dutchBBAN is the bank code of the BIC and the account number
+func dutchBBAN(account BankAccount) (string, error) {
+	code, err := bankCode(account)
+	if err != nil {
+		return "", err
+	}
+	return code + leftPad(account.AccountNumber, 10), nil
+}
+
+// belgianBBAN is the bank id, the account number and the remainder of their
+// division by 97 (97 instead of 0)
+func belgianBBAN(account BankAccount) (string, error) {
+	number := leftPad(account.BankID, 3) + leftPad(account.AccountNumber, 7)
+	check := mod97(number)
+	if check == 0 {
+		check = 97
+	}
+	return fmt.Sprintf("%s%02d", number, check), nil
+}
+
+// frenchBBAN is the bank and branch code of the bank id, the account number
+// and the RIB key
+func frenchBBAN(account BankAccount) (string, error) {
+	number := account.BankID + leftPad(strings.ToUpper(account.AccountNumber), 11)
+	if len(number) != 21 {
+		return "", nil
+	}
+	// the letters of the account number count as digits for the RIB key
+	digits := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return '1' + (r-'A')%9 + (r-'A')/18
+		}
+		return r
+	}, number)
+	bank, _ := strconv.ParseInt(digits[:5], 10, 64)
+	branch, _ := strconv.ParseInt(digits[5:10], 10, 64)
+	accountNumber, _ := strconv.ParseInt(digits[10:], 10, 64)
+	key := 97 - (89*bank+15*branch+3*accountNumber)%97
+	return fmt.Sprintf("%s%02d", number, key), nil
+}
+
+// spanishBBAN is the bank and branch code of the bank id, the two control
+// digits and the account number
+func spanishBBAN(account BankAccount) (string, error) {
+	accountNumber := leftPad(account.AccountNumber, 10)
+	if len(account.BankID) != 8 || accountNumber == "" {
+		return "", nil
+	}
+	return account.BankID + spanishControl("00"+account.BankID) + spanishControl(accountNumber) + accountNumber, nil
+}
+
+// spanishControl returns the control digit of ten digits
+func spanishControl(digits string) string {
+	weights := []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+	sum := 0
+	for i, r := range digits {
+		sum += int(r-'0') * weights[i]
+	}
+	control := 11 - sum%11
+	switch control {
+	case 10:
+		control = 1
+	case 11:
+		control = 0
+	}
+	return strconv.Itoa(control)
+}
+
+// portugueseBBAN is the bank and branch code of the bank id, the account
+// number and the two NIB check digits
+func portugueseBBAN(account BankAccount) (string, error) {
+	number := account.BankID + leftPad(account.AccountNumber, 11)
+	return fmt.Sprintf("%s%02d", number, 98-mod97(number+"00")), nil
+}
+
+// italianOdd are the CIN values of the characters at odd positions, indexed
+// by digit value or by letter
+var italianOdd = []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+// italianNumber is the structure of the BBAN of IT without the CIN
+var italianNumber = regexp.MustCompile(`^[0-9]{10}[A-Z0-9]{12}$`)
+
+// italianBBAN is the CIN check letter, the ABI and CAB codes of the bank id
+// and the account number
+func italianBBAN(account BankAccount) (string, error) {
+	number := account.BankID + leftPad(strings.ToUpper(account.AccountNumber), 12)
+	if !italianNumber.MatchString(number) {
+		return "", nil
+	}
+	sum := 0
+	for i, r := range number {
+		index := int(r - '0')
+		if r >= 'A' && r <= 'Z' {
+			index = int(r - 'A')
+		}
+		if i%2 == 0 {
+			sum += italianOdd[index]
+		} else {
+			sum += index
+		}
+	}
+	return string(rune('A'+sum%26)) + number, nil
+}
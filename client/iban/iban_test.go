@@ -0,0 +1,104 @@
+package iban
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate_validIBANs(t *testing.T) {
+	for _, iban := range []string{
+		"GB29 NWBK 6016 1331 9268 19",
+		"de89370400440532013000",
+		"BE68539007547034",
+		"FR1420041010050500013M02606",
+		"IT60X0542811101000000123456",
+		"ES9121000418450200051332",
+		"PT50000201231234567890154",
+		"CH9300762011623852957",
+		"NL91ABNA0417164300",
+		"LU280019400644750000",
+		"GR1601101250000000012300695",
+		"PL61109010140000071219812874",
+		"AT611904300234573201",
+	} {
+		assert.Nil(t, Validate(iban), iban)
+	}
+}
+
+func TestValidate_invalidIBANs(t *testing.T) {
+	cases := map[string]error{
+		"GB28NWBK60161331926819":      ErrInvalidChecksum,
+		"GB29NWBK6016133192681":       ErrInvalidLength,
+		"GB29NWB160161331926819":      ErrInvalidBBAN,
+		"US29NWBK60161331926819":      ErrUnsupportedCountry,
+		"GBXXNWBK60161331926819":      ErrInvalidChecksum,
+		"GB":                          ErrInvalidLength,
+		"DE8937040044053201300A":      ErrInvalidBBAN,
+		"NL91ABNA041716430":           ErrInvalidLength,
+		"IT60X0542811101000000123457": ErrInvalidChecksum,
+	}
+	for iban, expected := range cases {
+		err := Validate(iban)
+		assert.True(t, errors.Is(err, expected), "%s: %v", iban, err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	assert.EqualValues(t, "GB29NWBK60161331926819", Electronic(" gb29 nwbk 6016 1331 9268 19 "))
+	assert.EqualValues(t, "GB29 NWBK 6016 1331 9268 19", Print("GB29NWBK60161331926819"))
+	assert.EqualValues(t, "NL91 ABNA 0417 1643 00", Print("nl91abna0417164300"))
+	assert.EqualValues(t, "GB", Country("gb29nwbk60161331926819"))
+}
+
+func TestBankID(t *testing.T) {
+	bankID, ok := BankID("GB29 NWBK 6016 1331 9268 19")
+	assert.True(t, ok)
+	assert.EqualValues(t, "601613", bankID)
+
+	bankID, ok = BankID("IT60X0542811101000000123456")
+	assert.True(t, ok)
+	assert.EqualValues(t, "0542811101", bankID)
+
+	_, ok = BankID("NL91ABNA0417164300")
+	assert.False(t, ok)
+	_, ok = BankID("AT611904300234573201")
+	assert.False(t, ok)
+}
+
+func TestGenerate(t *testing.T) {
+	cases := map[string]BankAccount{
+		"GB29NWBK60161331926819":       {Country: "GB", Bic: "NWBKGB22", BankID: "601613", AccountNumber: "31926819"},
+		"DE89370400440532013000":       {Country: "DE", BankID: "37040044", AccountNumber: "532013000"},
+		"BE68539007547034":             {Country: "BE", BankID: "539", AccountNumber: "0075470"},
+		"FR1420041010050500013M02606":  {Country: "FR", BankID: "2004101005", AccountNumber: "0500013M026"},
+		"IT60X0542811101000000123456":  {Country: "IT", BankID: "0542811101", AccountNumber: "123456"},
+		"ES9121000418450200051332":     {Country: "ES", BankID: "21000418", AccountNumber: "0200051332"},
+		"PT50000201231234567890154":    {Country: "PT", BankID: "00020123", AccountNumber: "12345678901"},
+		"CH9300762011623852957":        {Country: "CH", BankID: "00762", AccountNumber: "011623852957"},
+		"NL91ABNA0417164300":           {Country: "NL", Bic: "ABNANL2A", AccountNumber: "417164300"},
+		"LU280019400644750000":         {Country: "LU", BankID: "001", AccountNumber: "9400644750000"},
+		"GR1601101250000000012300695":  {Country: "GR", BankID: "0110125", AccountNumber: "12300695"},
+		"PL61109010140000071219812874": {Country: "PL", BankID: "10901014", AccountNumber: "71219812874"},
+	}
+	for expected, account := range cases {
+		iban, err := Generate(account)
+		assert.Nil(t, err, expected)
+		assert.EqualValues(t, expected, iban)
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	_, err := Generate(BankAccount{Country: "US", BankID: "021000021", AccountNumber: "12345"})
+	assert.True(t, errors.Is(err, ErrUnsupportedCountry))
+
+	_, err = Generate(BankAccount{Country: "GB", BankID: "601613", AccountNumber: "31926819"})
+	assert.True(t, errors.Is(err, ErrInvalidBBAN))
+
+	_, err = Generate(BankAccount{Country: "DE", BankID: "3704004", AccountNumber: "123456789012"})
+	assert.True(t, errors.Is(err, ErrInvalidBBAN))
+
+	_, err = Generate(BankAccount{Country: "IT", BankID: "05428-1110", AccountNumber: "123456"})
+	assert.True(t, errors.Is(err, ErrInvalidBBAN))
+}
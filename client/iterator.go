@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AccountIterator walks through the accounts of the form3 api page by page,
+// following the json:api links.next of every page until a page has no next
+// link. Create it with Client.Accounts and use it like:
+//
+//	it := c.Accounts(&ListOptions{PageSize: 100})
+//	for it.Next(ctx) {
+//		account := it.Account()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type AccountIterator struct {
+	client  *Client
+	next    string
+	page    []AccountData
+	index   int
+	account AccountData
+	links   Links
+	meta    Meta
+	err     error
+	// linkErr is an invalid next link, reported once the page is walked through
+	linkErr error
+}
+
+// Accounts returns an AccountIterator that starts at the page of the options.
+// With nil options it starts at the first page with the default page size of
+// the form3 api.
+func (c *Client) Accounts(options *ListOptions) *AccountIterator {
+	return &AccountIterator{client: c, next: options.path()}
+}
+
+// Next advances to the next account, fetching the next page when needed. It
+// returns false when there are no more accounts or an error occurred, which
+// is then returned by Err.
+func (it *AccountIterator) Next(ctx context.Context) bool {
+
+	for it.err == nil {
+		if it.index < len(it.page) {
+			it.account = it.page[it.index]
+			it.index++
+			return true
+		}
+		if it.linkErr != nil {
+			it.err = it.linkErr
+			return false
+		}
+		if it.next == "" {
+			return false
+		}
+		it.fetch(ctx)
+	}
+	return false
+}
+
+// Account returns the current account
+func (it *AccountIterator) Account() AccountData {
+	return it.account
+}
+
+// Links returns the pagination links of the current page
+func (it *AccountIterator) Links() Links {
+	return it.links
+}
+
+// Meta returns the meta information of the current page
+func (it *AccountIterator) Meta() Meta {
+	return it.meta
+}
+
+// Err returns the error that stopped the iteration, nil when all accounts
+// were walked through
+func (it *AccountIterator) Err() error {
+	return it.err
+}
+
+// fetch gets the page of the next link
+func (it *AccountIterator) fetch(ctx context.Context) {
+
+	path := it.next
+	it.next = ""
+
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return
+	}
+
+	accounts, err := it.client.listPage(ctx, path)
+	if err != nil {
+		// a request cancelled on the way fails with a *url.Error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		it.err = err
+		return
+	}
+
+	it.page = accounts.Data
+	it.index = 0
+	it.links = accounts.Links
+	it.meta = accounts.Meta
+
+	next, err := it.client.nextPath(accounts.Links, len(accounts.Data), path)
+	if err != nil {
+		it.linkErr = err
+		return
+	}
+	it.next = next
+}
+
+// nextPath returns the path of the page after the page at path, which has
+// the given links and count accounts, or "" when it is the last page
+func (c *Client) nextPath(links Links, count int, path string) (string, error) {
+
+	if links.Next == "" || count == 0 {
+		return "", nil
+	}
+	next, err := c.linkPath(links.Next)
+	if err != nil {
+		return "", err
+	}
+	if next == path {
+		return "", fmt.Errorf("pagination link next %q points to the page itself", links.Next)
+	}
+	return next, nil
+}
+
+// listPage gets the page of accounts at the path relative to the base url
+func (c *Client) listPage(ctx context.Context, path string) (*AccountList, error) {
+
+	request, err := c.newRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.do(OperationList, request)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := &AccountList{}
+	if err := decodeResponse(response, accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// linkPath turns a pagination link into a path relative to the base url of
+// the Client. A link to another host is refused, so that the requests of the
+// Client never leave the configured api.
+func (c *Client) linkPath(link string) (string, error) {
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	if u.IsAbs() {
+		if !strings.HasPrefix(link, c.baseURL+"/") {
+			return "", fmt.Errorf("pagination link %q is outside of the base url %s", link, c.baseURL)
+		}
+		return strings.TrimPrefix(link, c.baseURL), nil
+	}
+	if u.Host != "" || !strings.HasPrefix(link, "/") {
+		return "", fmt.Errorf("pagination link %q is not a path of the api", link)
+	}
+	return link, nil
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAccounts returns n accounts with random ids
+func testAccounts(n int) []AccountData {
+	accounts := make([]AccountData, n)
+	for i := range accounts {
+		accounts[i] = AccountData{Type: "accounts", ID: guuid.New().String()}
+	}
+	return accounts
+}
+
+// testPage returns the page of pages asked for by the page[number] of the
+// request, with pagination links like the ones of the form3 api
+func testPage(r *http.Request, pages [][]AccountData) AccountList {
+
+	number, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+	size := r.URL.Query().Get("page[size]")
+	link := func(n int) string {
+		return fmt.Sprintf("/v1/organisation/accounts?page%%5Bnumber%%5D=%d&page%%5Bsize%%5D=%s", n, size)
+	}
+
+	list := AccountList{
+		Data:  pages[number],
+		Links: Links{First: link(0), Last: link(len(pages) - 1), Self: link(number)},
+		Meta:  Meta{"page_count": float64(len(pages))},
+	}
+	if number+1 < len(pages) {
+		list.Links.Next = link(number + 1)
+	}
+	if number > 0 {
+		list.Links.Prev = link(number - 1)
+	}
+	return list
+}
+
+func TestAccountIterator_followsNextLinks(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(2)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	var ids []string
+	it := c.Accounts(&ListOptions{PageSize: 2})
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Account().ID)
+	}
+
+	// validate
+	assert.Nil(t, it.Err())
+	assert.EqualValues(t, 6, len(ids))
+	assert.EqualValues(t, pages[0][0].ID, ids[0])
+	assert.EqualValues(t, pages[2][1].ID, ids[5])
+	// the last page is exactly page size long, yet no extra request is sent
+	assert.EqualValues(t, 3, requests)
+	assert.EqualValues(t, "", it.Links().Next)
+	assert.EqualValues(t, "/v1/organisation/accounts?page%5Bnumber%5D=1&page%5Bsize%5D=2", it.Links().Prev)
+	assert.EqualValues(t, 3, it.Meta()["page_count"])
+}
+
+func TestAccountIterator_whenPageFails_shouldStopWithError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2)}
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page[number]") == "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	count := 0
+	it := c.Accounts(&ListOptions{PageSize: 2})
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	// validate
+	assert.EqualValues(t, 2, count)
+	assert.True(t, IsNotFound(it.Err()))
+	assert.False(t, it.Next(context.Background()))
+}
+
+func TestAccountIterator_withoutLinks_shouldStopAfterFirstPage(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{Data: testAccounts(3)})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	count := 0
+	it := c.Accounts(nil)
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	// validate
+	assert.Nil(t, it.Err())
+	assert.EqualValues(t, 3, count)
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestAccountIterator_whenNextLinkIsOutsideBaseURL_shouldStopWithError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{Data: testAccounts(1),
+			Links: Links{Next: "https://attacker.example/v1/organisation/accounts?page%5Bnumber%5D=1"}})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	count := 0
+	it := c.Accounts(nil)
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	// validate
+	assert.EqualValues(t, 1, count)
+	assert.NotNil(t, it.Err())
+}
+
+func TestAccountIterator_whenNextLinkPointsToSamePage_shouldStopWithError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AccountList{Data: testAccounts(1), Links: Links{Next: r.URL.RequestURI()}})
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	count := 0
+	it := c.Accounts(&ListOptions{PageNumber: 0, PageSize: 1})
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	// validate
+	assert.NotNil(t, it.Err())
+	assert.EqualValues(t, 1, count)
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestLinkPath(t *testing.T) {
+	c, _ := NewClient("https://api.example.com/gateway")
+
+	path, err := c.linkPath("/v1/organisation/accounts?page%5Bnumber%5D=1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "/v1/organisation/accounts?page%5Bnumber%5D=1", path)
+
+	path, err = c.linkPath("https://api.example.com/gateway/v1/organisation/accounts?page%5Bnumber%5D=2")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "/v1/organisation/accounts?page%5Bnumber%5D=2", path)
+
+	_, err = c.linkPath("https://api.example.com.evil/gateway/v1/organisation/accounts")
+	assert.NotNil(t, err)
+	_, err = c.linkPath("//evil.example/v1/organisation/accounts")
+	assert.NotNil(t, err)
+	_, err = c.linkPath("v1/organisation/accounts")
+	assert.NotNil(t, err)
+}
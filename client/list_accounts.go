@@ -1,100 +1,161 @@
 package client
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
-// GetAccountsResponse ...
-type GetAccountsResponse struct {
-	Data []Data `json:"data"`
+// ListOptions holds the paging and filter parameters of a list request. A
+// PageSize of 0 leaves the page size to the form3 api.
+type ListOptions struct {
+	PageNumber int
+	PageSize   int
+	Filter     *AccountFilter
 }
 
-// Attributes ...
-type Attributes struct {
-	Country               string `json:"country"`
-	BaseCurrency          string `json:"base_currency"`
-	AccountNumber         string `json:"account_number"`
-	BankID                string `json:"bank_id"`
-	BankIDCode            string `json:"bank_id_code"`
-	Bic                   string `json:"bic"`
-	Iban                  string `json:"iban"`
-	AccountClassification string `json:"account_classification"`
-	JointAccount          bool   `json:"joint_account"`
-	Switched              bool   `json:"switched"`
-	AccountMatchingOptOut bool   `json:"account_matching_opt_out"`
-	Status                string `json:"status"`
+// AccountFilter holds the filter parameters of a list request. Only the
+// accounts that match every non empty field are listed.
+type AccountFilter struct {
+	BankID        string
+	BankIDCode    string
+	AccountNumber string
+	Iban          string
+	CustomerID    string
+	Country       string
 }
 
-// Data ...
-type Data struct {
-	Type           string     `json:"type"`
-	ID             string     `json:"id"`
-	OrganisationID string     `json:"organisation_id"`
-	Version        int        `json:"version"`
-	Attributes     Attributes `json:"attributes"`
+// path returns the list accounts path with the url encoded query parameters
+// of the options
+func (o *ListOptions) path() string {
+	if o == nil {
+		return accountsPath
+	}
+
+	query := url.Values{}
+	query.Set("page[number]", strconv.Itoa(o.PageNumber))
+	if o.PageSize > 0 {
+		query.Set("page[size]", strconv.Itoa(o.PageSize))
+	}
+	o.Filter.addTo(query)
+	return accountsPath + "?" + query.Encode()
+}
+
+// addTo adds the filter[...] parameters of the non empty fields to query
+func (f *AccountFilter) addTo(query url.Values) {
+	if f == nil {
+		return
+	}
+
+	for key, value := range map[string]string{
+		"bank_id":        f.BankID,
+		"bank_id_code":   f.BankIDCode,
+		"account_number": f.AccountNumber,
+		"iban":           f.Iban,
+		"customer_id":    f.CustomerID,
+		"country":        f.Country,
+	} {
+		if value != "" {
+			query.Set("filter["+key+"]", value)
+		}
+	}
 }
 
 // ListAccounts calls the form3 api with the specified pageNumber and pageSize
+//
+// Deprecated: construct a Client with NewClient and use Client.ListAccounts
 func ListAccounts(host string, pageNumber, pageSize int) (*http.Response, error) {
-	fmt.Println("in ListAccounts", "pageNumber", pageNumber, "pageSize", pageSize)
+	return hostClient(host).ListAccounts(pageNumber, pageSize)
+}
+
+// ListAccounts calls the form3 api with the specified pageNumber and pageSize
+func (c *Client) ListAccounts(pageNumber, pageSize int) (*http.Response, error) {
+
+	options := &ListOptions{PageNumber: pageNumber, PageSize: pageSize}
+
+	request, err := c.newRequest(http.MethodGet, options.path(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	uri := "/v1/organisation/accounts?"
+	return c.do(OperationList, request)
+}
+
+// ListAccountsWithContext is ListAccounts bound to ctx, with the paging and
+// filter parameters of options. With nil options no parameters are sent.
+func (c *Client) ListAccountsWithContext(ctx context.Context, options *ListOptions) (*http.Response, error) {
 
-	request, err := RequestCreator(http.MethodGet, host+uri+"page[number]="+fmt.Sprint(pageNumber)+"&page[size]="+fmt.Sprint(pageSize), nil)
+	request, err := c.newRequestWithContext(ctx, http.MethodGet, options.path(), nil)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
-	client := &http.Client{}
-	return client.Do(request)
+	return c.do(OperationList, request)
 }
 
-// UnmarshallGetAccountsResponse returns the  GetAccountsResponse struct from the http.Response
-func UnmarshallGetAccountsResponse(response *http.Response) (*GetAccountsResponse, error) {
+// List gets a page of accounts. With nil options the first page is returned
+// with the default page size of the form3 api.
+func (c *Client) List(ctx context.Context, options *ListOptions) ([]AccountData, error) {
+
+	response, err := c.ListAccountsWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := &AccountList{}
+	if err := decodeResponse(response, accounts); err != nil {
+		return nil, err
+	}
+	return accounts.Data, nil
+}
+
+// UnmarshallGetAccountsResponse returns the  AccountList struct from the http.Response
+func UnmarshallGetAccountsResponse(response *http.Response) (*AccountList, error) {
+	defer drainAndClose(response.Body)
 
 	byteArr, err := IOResponseBodyReader(response.Body)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
-	accounts := &GetAccountsResponse{}
+	accounts := &AccountList{}
 	err = Unmarshaller(byteArr, &accounts)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
 	return accounts, nil
 }
 
-// GatherAccounts gets the list of all existing accounts in db by calling
-// the 'ListAccounts'
-func GatherAccounts(host string, pageSize int) (allAccs []Data) {
-
-	allAccs = make([]Data, 0)
-	listAccountsStatusCode := 200
+// GatherAccounts gets the list of all existing accounts in db by following
+// the pagination links of the form3 api
+//
+// Deprecated: construct a Client with NewClient and use Client.GatherAccounts
+func GatherAccounts(host string, pageSize int) []AccountData {
+	return hostClient(host).GatherAccounts(pageSize)
+}
 
-	for pageNumber := 0; listAccountsStatusCode == 200; pageNumber = pageNumber + 1 {
+// GatherAccounts gets the list of all existing accounts in db by following
+// the pagination links of the form3 api. It stops silently at the first
+// error; use GatherAccountsWithContext to get the error.
+func (c *Client) GatherAccounts(pageSize int) []AccountData {
+	allAccs, _ := c.GatherAccountsWithContext(context.Background(), pageSize)
+	return allAccs
+}
 
-		getAccountsResponse, err := ListAccounts(host, pageNumber, pageSize)
+// GatherAccountsWithContext gets the list of all existing accounts in db by
+// following the pagination links of the form3 api with an AccountIterator. It
+// stops paging as soon as ctx is cancelled or a page fails, and returns the
+// accounts gathered so far together with the error.
+func (c *Client) GatherAccountsWithContext(ctx context.Context, pageSize int) ([]AccountData, error) {
 
-		listAccountsStatusCode = getAccountsResponse.StatusCode
-		accounts, err := UnmarshallGetAccountsResponse(getAccountsResponse)
+	allAccs := make([]AccountData, 0)
 
-		if err == nil && listAccountsStatusCode == 200 && len(accounts.Data) > 0 {
-			for _, d := range accounts.Data {
-				allAccs = append(allAccs, d)
-			}
-			if len(accounts.Data) < pageSize {
-				break
-			}
-		} else {
-			break
-		}
+	it := c.Accounts(&ListOptions{PageNumber: 0, PageSize: pageSize})
+	for it.Next(ctx) {
+		allAccs = append(allAccs, it.Account())
 	}
 
-	return allAccs
+	return allAccs, it.Err()
 }
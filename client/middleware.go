@@ -0,0 +1,215 @@
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps the http.RoundTripper that sends the requests of a Client
+// to add a cross-cutting behaviour, e.g. logging or metrics, to all its
+// operations. A Middleware returns an http.RoundTripper that handles the
+// request, usually by calling next; like any http.RoundTripper it must not
+// modify the request, but a clone of it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an http.RoundTripper made of a function, to write a
+// Middleware with:
+//
+//	func(next http.RoundTripper) http.RoundTripper {
+//		return client.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+//			// before the request
+//			response, err := next.RoundTrip(request)
+//			// after the response
+//			return response, err
+//		})
+//	}
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// WithMiddleware adds Middlewares to the Client. They are composed in the
+// order they are added, over all the calls of WithMiddleware: the first one is
+// the outermost, which sees the request first and the response last, and the
+// last one calls the transport. The chain runs inside the retries, the rate
+// limiters and the Authenticator of the Client, so every attempt of a request
+// passes through it, authenticated.
+//
+// Retries are deliberately not a Middleware but stay in the Client, set with
+// WithRetryPolicy: a retry waits again for the rate limiters of the operation,
+// is authenticated again, e.g. with a new signature date or OAuth2 token, and
+// is sent again with its body from GetBody. A RoundTripper sees none of this,
+// and would retry requests already signed, rate limited as a single request
+// and with a body already read.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// middlewareChain is the http.RoundTripper of a Client with Middlewares
+type middlewareChain struct {
+	http.RoundTripper
+	transport http.RoundTripper
+}
+
+// chainMiddlewares wraps transport in the middlewares, the first one outermost
+func chainMiddlewares(transport http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	roundTripper := transport
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		roundTripper = middlewares[i](roundTripper)
+	}
+	return &middlewareChain{RoundTripper: roundTripper, transport: transport}
+}
+
+// CloseIdleConnections closes the idle connections of the transport under
+// the middlewares, for http.Client.CloseIdleConnections
+func (m *middlewareChain) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if transport, ok := m.transport.(closeIdler); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// LoggingMiddleware logs every request with its method, path, status and
+// duration, or its error. A nil logger logs to the standard error.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+			duration := time.Since(start).Round(time.Microsecond)
+			if err != nil {
+				logger.Printf("%s %s failed after %v: %v", request.Method, request.URL.Path, duration, err)
+			} else {
+				logger.Printf("%s %s %d in %v", request.Method, request.URL.Path, response.StatusCode, duration)
+			}
+			return response, err
+		})
+	}
+}
+
+// RoundTripMetrics describes a request sent through the MetricsMiddleware
+type RoundTripMetrics struct {
+	Method string
+	Path   string
+	// StatusCode is 0 when no response was received
+	StatusCode int
+	// Duration is the time until the response headers were received
+	Duration time.Duration
+	Err      error
+}
+
+// MetricsMiddleware calls record after every request, e.g. to feed a
+// histogram of the durations by status
+func MetricsMiddleware(record func(RoundTripMetrics)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+
+			metrics := RoundTripMetrics{Method: request.Method, Path: request.URL.Path, Duration: time.Since(start), Err: err}
+			if response != nil {
+				metrics.StatusCode = response.StatusCode
+			}
+			record(metrics)
+			return response, err
+		})
+	}
+}
+
+// AuthenticatorMiddleware authenticates every request with the Authenticator.
+// It lets an Authenticator be used by another http.Client; a Client is better
+// given its Authenticator with WithAuthenticator, which also retries a request
+// refused with 401 with new credentials.
+func AuthenticatorMiddleware(authenticator Authenticator) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			authenticated := request.Clone(request.Context())
+			if err := authenticator.Authenticate(authenticated); err != nil {
+				if request.Body != nil {
+					request.Body.Close()
+				}
+				return nil, err
+			}
+			return next.RoundTrip(authenticated)
+		})
+	}
+}
+
+// ErrInjectedFault is the error of a request failed by the FaultInjectionMiddleware
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultConfig configures the FaultInjectionMiddleware
+type FaultConfig struct {
+	// Probability is the fraction, between 0 and 1, of the requests that fail
+	Probability float64
+	// StatusCode is the status of the response of a failed request. 0 fails
+	// the request with ErrInjectedFault instead, like a transport error.
+	StatusCode int
+	// Delay is added to every request, failed or not
+	Delay time.Duration
+}
+
+// FaultInjectionMiddleware fails a random share of the requests, without
+// sending them, and delays the requests, to test how the callers of the
+// Client cope with a failing or slow form3 api
+func FaultInjectionMiddleware(config FaultConfig) Middleware {
+
+	var mu sync.Mutex
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	fail := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return random.Float64() < config.Probability
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if config.Delay > 0 {
+				if err := sleep(request.Context(), config.Delay); err != nil {
+					return nil, err
+				}
+			}
+			if !fail() {
+				return next.RoundTrip(request)
+			}
+
+			if request.Body != nil {
+				request.Body.Close()
+			}
+			if config.StatusCode == 0 {
+				return nil, ErrInjectedFault
+			}
+			return &http.Response{
+				Status:     http.StatusText(config.StatusCode),
+				StatusCode: config.StatusCode,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error_message":"injected fault"}`)),
+				Request:    request,
+			}, nil
+		})
+	}
+}
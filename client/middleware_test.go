@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticAuthenticator authenticates with a fixed Authorization header
+type staticAuthenticator string
+
+func (a staticAuthenticator) Authenticate(request *http.Request) error {
+	request.Header.Set("Authorization", string(a))
+	return nil
+}
+
+// tracingMiddleware records when a request enters and leaves it
+func tracingMiddleware(name string, trace *[]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			*trace = append(*trace, name+" "+request.Header.Get("Authorization"))
+			response, err := next.RoundTrip(request)
+			*trace = append(*trace, "/"+name)
+			return response, err
+		})
+	}
+}
+
+func TestWithMiddleware_shouldComposeInOrder(t *testing.T) {
+	// prepare
+	restoreInits()
+	requests := 0
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"0673746b-8dd3-4bd2-b398-941bdf2865df"}}`))
+	})
+	defer server.Close()
+
+	var trace []string
+	var metrics []RoundTripMetrics
+	c, _ := NewClient(server.URL,
+		WithAuthenticator(staticAuthenticator("token")),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, RetryStatusCodes: []int{http.StatusServiceUnavailable}}),
+		WithMiddleware(tracingMiddleware("first", &trace), tracingMiddleware("second", &trace)),
+		WithMiddleware(MetricsMiddleware(func(m RoundTripMetrics) { metrics = append(metrics, m) })))
+
+	// test
+	account, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// validate
+	assert.Nil(t, err)
+	assert.NotNil(t, account)
+	assert.EqualValues(t, []string{
+		"first token", "second token", "/second", "/first",
+		"first token", "second token", "/second", "/first",
+	}, trace)
+	assert.EqualValues(t, 2, len(metrics))
+	assert.EqualValues(t, http.StatusServiceUnavailable, metrics[0].StatusCode)
+	assert.EqualValues(t, http.StatusOK, metrics[1].StatusCode)
+	assert.EqualValues(t, "/v1/organisation/accounts/0673746b-8dd3-4bd2-b398-941bdf2865df", metrics[1].Path)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	// prepare
+	restoreInits()
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+	output := &bytes.Buffer{}
+	c, _ := NewClient(server.URL, WithMiddleware(LoggingMiddleware(log.New(output, "", 0))))
+
+	// test
+	_, err := c.Fetch(context.Background(), "42")
+
+	// validate
+	assert.True(t, IsNotFound(err))
+	assert.Contains(t, output.String(), "GET /v1/organisation/accounts/42 404 in ")
+}
+
+func TestFaultInjectionMiddleware(t *testing.T) {
+	// prepare
+	restoreInits()
+	requests := 0
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{"id":"42"}}`))
+	})
+	defer server.Close()
+	newFaultyClient := func(config FaultConfig) *Client {
+		c, _ := NewClient(server.URL, WithRetryPolicy(NoRetry), WithMiddleware(FaultInjectionMiddleware(config)))
+		return c
+	}
+
+	// test & validate
+	_, err := newFaultyClient(FaultConfig{Probability: 1, StatusCode: http.StatusServiceUnavailable}).Fetch(context.Background(), "42")
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, http.StatusServiceUnavailable, apiError.StatusCode)
+	assert.EqualValues(t, "injected fault", apiError.ErrorMessage)
+
+	_, err = newFaultyClient(FaultConfig{Probability: 1}).Fetch(context.Background(), "42")
+	assert.True(t, errors.Is(err, ErrInjectedFault))
+	assert.EqualValues(t, 0, requests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = newFaultyClient(FaultConfig{Delay: time.Hour}).Fetch(ctx, "42")
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	account, err := newFaultyClient(FaultConfig{}).Fetch(context.Background(), "42")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "42", account.ID)
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestAuthenticatorMiddleware_shouldNotModifyTheRequest(t *testing.T) {
+	// prepare
+	var authorization string
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		authorization = request.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: request}, nil
+	})
+	httpClient := &http.Client{Transport: AuthenticatorMiddleware(staticAuthenticator("token"))(transport)}
+	request, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/v1/organisation/accounts", nil)
+
+	// test
+	response, err := httpClient.Do(request)
+
+	// validate
+	assert.Nil(t, err)
+	response.Body.Close()
+	assert.EqualValues(t, "token", authorization)
+	assert.EqualValues(t, "", request.Header.Get("Authorization"))
+}
+
+func TestWithMiddleware_shouldCloseIdleConnectionsOfTheTransport(t *testing.T) {
+	// prepare
+	restoreInits()
+	server, connections := newConnCountingServer(3)
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithTransportConfig(DefaultTransportConfig()),
+		WithMiddleware(MetricsMiddleware(func(RoundTripMetrics) {})))
+
+	// test
+	_, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+	assert.Nil(t, err)
+	c.CloseIdleConnections()
+	_, err = c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+	assert.Nil(t, err)
+	c.CloseIdleConnections()
+
+	// validate
+	assert.EqualValues(t, 2, atomic.LoadInt32(connections))
+}
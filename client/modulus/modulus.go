@@ -0,0 +1,314 @@
+// Package modulus implements the VocaLink modulus checking of UK sort codes
+// and account numbers.
+//
+// The checks are driven by the VocaLink weight table (valacdos.txt), loaded
+// with LoadTable or LoadTableFile, and optionally by the sort code
+// substitution table (scsubtab.txt) of exception 5, loaded with
+// LoadSubstitutions. Both files are published by VocaLink and change a few
+// times a year, which is why they are not built in.
+package modulus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidFormat is returned for a sort code or account number that
+	// does not have the digits of a UK account
+	ErrInvalidFormat = errors.New("invalid sort code or account number format")
+	// ErrFailedCheck is returned when the account number fails the modulus
+	// check of its sort code
+	ErrFailedCheck = errors.New("sort code and account number fail the modulus check")
+)
+
+// The check methods of the weight table
+const (
+	methodMod10 = "MOD10"
+	methodMod11 = "MOD11"
+	methodDblAl = "DBLAL"
+)
+
+// rule is a row of the weight table: the check of a range of sort codes
+type rule struct {
+	start, end string
+	method     string
+	// weights are the weights of the digits u v w x y z a b c d e f g h, the
+	// sort code uvwxyz followed by the account number abcdefgh
+	weights   [14]int
+	exception int
+}
+
+// Table is a loaded weight table. It is safe for concurrent use once loaded.
+type Table struct {
+	rules         []rule
+	substitutions map[string]string
+}
+
+// LoadTableFile loads a weight table from a file in the format of valacdos.txt
+func LoadTableFile(path string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadTable(file)
+}
+
+// LoadTable loads a weight table in the format of valacdos.txt: one rule per
+// line made of the first and last sort code of the range, the method (MOD10,
+// MOD11 or DBLAL), the 14 weights and an optional exception number
+func LoadTable(r io.Reader) (*Table, error) {
+
+	table := &Table{substitutions: map[string]string{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		rule, err := parseRule(fields)
+		if err != nil {
+			return nil, fmt.Errorf("weight table line %d: %v", line, err)
+		}
+		table.rules = append(table.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// parseRule parses the fields of a line of the weight table
+func parseRule(fields []string) (rule, error) {
+
+	r := rule{}
+	if len(fields) != 17 && len(fields) != 18 {
+		return r, fmt.Errorf("expected 17 or 18 fields, got %d", len(fields))
+	}
+	r.start, r.end, r.method = fields[0], fields[1], fields[2]
+	if !isDigits(r.start, 6) || !isDigits(r.end, 6) || r.start > r.end {
+		return r, fmt.Errorf("invalid sort code range %s %s", r.start, r.end)
+	}
+	if r.method != methodMod10 && r.method != methodMod11 && r.method != methodDblAl {
+		return r, fmt.Errorf("unknown method %s", r.method)
+	}
+	for i := range r.weights {
+		weight, err := strconv.Atoi(fields[3+i])
+		if err != nil {
+			return r, fmt.Errorf("invalid weight %s", fields[3+i])
+		}
+		r.weights[i] = weight
+	}
+	if len(fields) == 18 {
+		exception, err := strconv.Atoi(fields[17])
+		if err != nil {
+			return r, fmt.Errorf("invalid exception %s", fields[17])
+		}
+		r.exception = exception
+	}
+	return r, nil
+}
+
+// LoadSubstitutions loads the sort code substitution table of exception 5, in
+// the format of scsubtab.txt: one sort code and its substitute per line
+func (t *Table) LoadSubstitutions(r io.Reader) error {
+
+	substitutions := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || !isDigits(fields[0], 6) || !isDigits(fields[1], 6) {
+			return fmt.Errorf("substitution table line %d: expected two sort codes", line)
+		}
+		substitutions[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	t.substitutions = substitutions
+	return nil
+}
+
+// Check checks an account number against its sort code. The sort code may
+// contain dashes and the account number may have 6 to 8 digits. A sort code
+// the table has no rule for cannot be checked and is accepted, as VocaLink
+// requires.
+func (t *Table) Check(sortCode, accountNumber string) error {
+
+	sortCode = strings.Replace(strings.TrimSpace(sortCode), "-", "", -1)
+	accountNumber = strings.TrimSpace(accountNumber)
+	if !isDigits(sortCode, 6) || len(accountNumber) < 6 || !isDigits(accountNumber, len(accountNumber)) || len(accountNumber) > 8 {
+		return fmt.Errorf("%w: %q %q", ErrInvalidFormat, sortCode, accountNumber)
+	}
+	accountNumber = strings.Repeat("0", 8-len(accountNumber)) + accountNumber
+
+	rules := t.lookup(sortCode)
+	if len(rules) == 0 || t.valid(sortCode, accountNumber, rules) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s", ErrFailedCheck, sortCode, accountNumber)
+}
+
+// lookup returns the rules of a sort code, at most two
+func (t *Table) lookup(sortCode string) []rule {
+	var rules []rule
+	for _, r := range t.rules {
+		if r.start <= sortCode && sortCode <= r.end {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) > 2 {
+		rules = rules[:2]
+	}
+	return rules
+}
+
+// valid runs the checks of the rules of a sort code, combining the results of
+// two rules as their exceptions require
+func (t *Table) valid(sortCode, accountNumber string, rules []rule) bool {
+
+	first := rules[0]
+	// exception 6: foreign currency accounts cannot be checked
+	if first.exception == 6 && accountNumber[0] >= '4' && accountNumber[0] <= '8' && accountNumber[6] == accountNumber[7] {
+		return true
+	}
+
+	ok := t.check(first, sortCode, accountNumber)
+	if len(rules) == 1 {
+		return ok
+	}
+
+	second := rules[1]
+	switch {
+	case first.exception == 2 && second.exception == 9:
+		// the second check is only run, with another sort code, when the first fails
+		return ok || t.check(second, "309634", accountNumber)
+	case first.exception == 10 && second.exception == 11, first.exception == 12 && second.exception == 13:
+		// either check passing is enough
+		return ok || t.check(second, sortCode, accountNumber)
+	case second.exception == 3 && (accountNumber[2] == '6' || accountNumber[2] == '9'):
+		// the second check is skipped when c is 6 or 9
+		return ok
+	}
+	return ok && t.check(second, sortCode, accountNumber)
+}
+
+// check runs the check of one rule
+func (t *Table) check(r rule, sortCode, accountNumber string) bool {
+
+	weights := r.weights
+	a, g := accountNumber[0], accountNumber[6]
+
+	switch r.exception {
+	case 2:
+		if a != '0' && g != '9' {
+			weights = [14]int{0, 0, 1, 2, 5, 3, 6, 4, 8, 7, 10, 9, 3, 1}
+		} else if a != '0' {
+			weights = [14]int{0, 0, 0, 0, 0, 0, 0, 0, 8, 7, 10, 9, 3, 1}
+		}
+	case 5:
+		if substitute, ok := t.substitutions[sortCode]; ok {
+			sortCode = substitute
+		}
+	case 7:
+		if g == '9' {
+			weights = zeroiseSortCode(weights)
+		}
+	case 8:
+		sortCode = "090126"
+	case 10:
+		if (accountNumber[:2] == "09" || accountNumber[:2] == "99") && g == '9' {
+			weights = zeroiseSortCode(weights)
+		}
+	}
+
+	digits := sortCode + accountNumber
+	total := 0
+	for i, weight := range weights {
+		product := int(digits[i]-'0') * weight
+		if r.method == methodDblAl {
+			product = product/10 + product%10
+		}
+		total += product
+	}
+
+	switch r.method {
+	case methodMod10:
+		return total%10 == 0
+	case methodMod11:
+		return t.checkMod11(r, sortCode, accountNumber, total)
+	default:
+		if r.exception == 1 {
+			total += 27
+		}
+		if r.exception == 5 {
+			return checkDigit(10-total%10, 10) == int(accountNumber[7]-'0')
+		}
+		return total%10 == 0
+	}
+}
+
+// checkMod11 returns the result of a MOD11 check with the given total
+func (t *Table) checkMod11(r rule, sortCode, accountNumber string, total int) bool {
+
+	switch r.exception {
+	case 4:
+		gh, _ := strconv.Atoi(accountNumber[6:])
+		return total%11 == gh
+	case 5:
+		remainder := total % 11
+		return remainder != 1 && checkDigit(11-remainder, 11) == int(accountNumber[6]-'0')
+	case 14:
+		if total%11 == 0 {
+			return true
+		}
+		// the last digit may be a suffix: 0, 1 or 9, dropped before checking again
+		h := accountNumber[7]
+		if h != '0' && h != '1' && h != '9' {
+			return false
+		}
+		r.exception = 0
+		return t.check(r, sortCode, "0"+accountNumber[:7])
+	}
+	return total%11 == 0
+}
+
+// checkDigit returns the check digit of a complement to the modulus, 0 when
+// the remainder was 0
+func checkDigit(complement, modulus int) int {
+	if complement == modulus {
+		return 0
+	}
+	return complement
+}
+
+// zeroiseSortCode returns the weights with the weights of u to b set to 0
+func zeroiseSortCode(weights [14]int) [14]int {
+	for i := 0; i < 8; i++ {
+		weights[i] = 0
+	}
+	return weights
+}
+
+// isDigits reports whether s is n decimal digits
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package modulus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTable loads a weight table made of the given lines
+func testTable(t *testing.T, lines ...string) *Table {
+	table, err := LoadTable(strings.NewReader(strings.Join(lines, "\n")))
+	assert.Nil(t, err)
+	return table
+}
+
+// assertChecks asserts the result of Check for accounts of a sort code
+func assertChecks(t *testing.T, table *Table, sortCode string, valid, invalid []string) {
+	for _, accountNumber := range valid {
+		assert.Nil(t, table.Check(sortCode, accountNumber), "%s %s", sortCode, accountNumber)
+	}
+	for _, accountNumber := range invalid {
+		assert.True(t, errors.Is(table.Check(sortCode, accountNumber), ErrFailedCheck), "%s %s", sortCode, accountNumber)
+	}
+}
+
+func TestCheck_standardMethods(t *testing.T) {
+	table, err := LoadTableFile("testdata/valacdos.txt")
+	assert.Nil(t, err)
+
+	assertChecks(t, table, "08-99-99", []string{"66374958"}, []string{"66374959"})
+	assertChecks(t, table, "107999", []string{"88837491"}, []string{"88837493"})
+	assertChecks(t, table, "202959", []string{"63748472"}, []string{"63748473"})
+	// no rule: cannot be checked, so accepted
+	assertChecks(t, table, "999999", []string{"12345678"}, nil)
+}
+
+func TestCheck_exception3_skipsSecondCheckWhenCIs6Or9(t *testing.T) {
+	table, err := LoadTableFile("testdata/valacdos.txt")
+	assert.Nil(t, err)
+
+	// both pass MOD11, only the first fails DBLAL
+	assertChecks(t, table, "820000", []string{"00600008"}, []string{"00500003"})
+}
+
+func TestCheck_exceptions2And9_secondCheckWithSubstitutedSortCode(t *testing.T) {
+	table := testTable(t,
+		"309000 309999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 0 1 2",
+		"309000 309999 MOD11 0 0 0 0 0 1 0 0 0 0 0 0 0 1 9")
+
+	assertChecks(t, table, "309070", []string{"01234560", "01234567"}, []string{"01234568"})
+}
+
+func TestCheck_exceptions10And11_eitherCheckPasses(t *testing.T) {
+	table := testTable(t,
+		"871000 871999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 0 1 10",
+		"871000 871999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 2 1 11")
+
+	assertChecks(t, table, "871427", []string{"00000050", "00000051"}, []string{"00000011"})
+}
+
+func TestCheck_exception6_acceptsForeignCurrencyAccounts(t *testing.T) {
+	table := testTable(t, "200000 200999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 0 1 6")
+
+	assertChecks(t, table, "200915", []string{"40000055"}, []string{"30000055"})
+}
+
+func TestCheck_exception4_remainderEqualsGH(t *testing.T) {
+	table := testTable(t, "827000 827999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 0 1 4")
+
+	assertChecks(t, table, "827101", []string{"00000005"}, []string{"00000015"})
+}
+
+func TestCheck_exception14_dropsSuffix(t *testing.T) {
+	table := testTable(t, "180000 180999 MOD11 0 0 0 0 0 0 0 0 0 0 0 0 1 0 14")
+
+	assertChecks(t, table, "180002", []string{"00000509", "00000051"}, []string{"00000359", "00000055"})
+}
+
+func TestCheck_exception5_checkDigits(t *testing.T) {
+	table := testTable(t,
+		"938000 938999 MOD11 0 0 0 0 0 0 0 0 0 0 0 1 0 0 5",
+		"938000 938999 DBLAL 0 0 0 0 0 0 0 0 0 0 1 0 0 0 5")
+
+	assertChecks(t, table, "938063", []string{"00003007", "00000290"}, []string{"00003008", "00000100", "00000280"})
+}
+
+func TestCheck_exception5_substitutesSortCode(t *testing.T) {
+	table := testTable(t, "938000 938999 MOD11 0 0 0 0 0 1 0 0 0 0 0 0 0 0 5")
+	assertChecks(t, table, "938611", nil, []string{"00000000"})
+
+	err := table.LoadSubstitutions(strings.NewReader("938611 938600\n938613 938600\n"))
+	assert.Nil(t, err)
+	assertChecks(t, table, "938611", []string{"00000000"}, nil)
+}
+
+func TestCheck_exceptions1_7_8(t *testing.T) {
+	table := testTable(t,
+		"118000 118999 DBLAL 0 0 0 0 0 0 0 0 0 0 0 0 0 1 1",
+		"134000 134999 MOD11 1 0 0 0 0 0 0 0 0 0 0 0 0 1 7",
+		"772000 772999 MOD11 0 0 1 0 0 0 0 0 0 0 0 0 0 0 8")
+
+	assertChecks(t, table, "118765", []string{"00000003"}, []string{"00000000"})
+	assertChecks(t, table, "134020", []string{"00000090"}, []string{"00000080"})
+	assertChecks(t, table, "772798", []string{"00000000"}, nil)
+}
+
+func TestCheck_invalidFormat(t *testing.T) {
+	table := testTable(t)
+
+	for _, input := range [][2]string{{"12345", "12345678"}, {"12345a", "12345678"}, {"123456", "12345"}, {"123456", "123456789"}, {"123456", "1234567x"}} {
+		assert.True(t, errors.Is(table.Check(input[0], input[1]), ErrInvalidFormat), "%v", input)
+	}
+	// 6 and 7 digit account numbers are padded with zeros
+	assert.Nil(t, table.Check("123456", "123456"))
+}
+
+func TestLoadTable_errors(t *testing.T) {
+	for _, line := range []string{
+		"089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7",
+		"089999 089000 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1",
+		"089000 089999 MOD12 0 0 0 0 0 0 7 1 3 7 1 3 7 1",
+		"089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 x",
+		"089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1 x",
+	} {
+		_, err := LoadTable(strings.NewReader("\n" + line))
+		assert.NotNil(t, err, line)
+		assert.Contains(t, err.Error(), "line 2")
+	}
+
+	table := testTable(t)
+	assert.NotNil(t, table.LoadSubstitutions(strings.NewReader("938611")))
+}
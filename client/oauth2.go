@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenExpiryDelta is how long before its expiry a token is renewed
+// unless configured otherwise
+const DefaultTokenExpiryDelta = 30 * time.Second
+
+// OAuth2Config configures an OAuth2Authenticator
+type OAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes are the requested scopes, none when empty
+	Scopes []string
+	// HTTPClient sends the token requests. nil means a new http.Client.
+	HTTPClient *http.Client
+	// ExpiryDelta is how long before its expiry a token is renewed, so that
+	// it does not expire on the way to the form3 api. It is at most half the
+	// lifetime of the token, so that a short lived token is still reused.
+	// 0 means DefaultTokenExpiryDelta.
+	ExpiryDelta time.Duration
+}
+
+// TokenError is returned when the token endpoint refuses to issue a token
+type TokenError struct {
+	StatusCode int
+	// ErrorCode and Description are the OAuth2 error and error_description
+	ErrorCode   string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	message := fmt.Sprintf("token request failed with status %d", e.StatusCode)
+	if e.ErrorCode != "" {
+		message += ": " + e.ErrorCode
+	}
+	if e.Description != "" {
+		message += ": " + e.Description
+	}
+	return message
+}
+
+// OAuth2Authenticator is an Authenticator that sends a bearer token obtained
+// with the OAuth2 client credentials grant. The token is cached and shared by
+// all requests until shortly before it expires. When it has to be renewed
+// one request fetches a new token while the concurrent ones wait for it, so a
+// burst of requests costs a single token request. A request answered with 401
+// Unauthorized is sent once more with a new token.
+type OAuth2Authenticator struct {
+	config OAuth2Config
+	now    func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	// renewAt is when the token is renewed, zero when it does not expire
+	renewAt time.Time
+	// refreshing is closed when the running token request is done, nil when
+	// none is running
+	refreshing chan struct{}
+}
+
+// NewOAuth2Authenticator creates an OAuth2Authenticator. No token is requested
+// before the first request.
+func NewOAuth2Authenticator(config OAuth2Config) *OAuth2Authenticator {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+	if config.ExpiryDelta == 0 {
+		config.ExpiryDelta = DefaultTokenExpiryDelta
+	}
+	return &OAuth2Authenticator{config: config, now: time.Now}
+}
+
+// Authenticate implements Authenticator. A token request is bound to the
+// context of the request.
+func (a *OAuth2Authenticator) Authenticate(request *http.Request) error {
+
+	token, err := a.Token(request.Context())
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Reauthenticate implements Reauthenticator: the token the request was
+// refused with is dropped, so that the next request fetches a new one
+func (a *OAuth2Authenticator) Reauthenticate(request *http.Request) bool {
+
+	refused := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.accessToken == refused {
+		a.accessToken = ""
+	}
+	return true
+}
+
+// Token returns a valid access token, from the cache or from the token
+// endpoint when the cached one expires soon
+func (a *OAuth2Authenticator) Token(ctx context.Context) (string, error) {
+
+	for {
+		a.mu.Lock()
+		if a.accessToken != "" && (a.renewAt.IsZero() || a.now().Before(a.renewAt)) {
+			token := a.accessToken
+			a.mu.Unlock()
+			return token, nil
+		}
+
+		refreshing := a.refreshing
+		if refreshing == nil {
+			// fetch the token ourselves while the others wait for it
+			done := make(chan struct{})
+			a.refreshing = done
+			a.mu.Unlock()
+
+			token, renewAt, err := a.fetchToken(ctx)
+
+			a.mu.Lock()
+			if err == nil {
+				a.accessToken, a.renewAt = token, renewAt
+			}
+			a.refreshing = nil
+			close(done)
+			a.mu.Unlock()
+			return token, err
+		}
+		a.mu.Unlock()
+
+		// wait for the token request of another goroutine, and try again
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// tokenResponse is the json of a successful or failed token response
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetchToken requests a token from the token endpoint with the client
+// credentials grant, and returns it with the time it is to be renewed, zero
+// when the token endpoint did not tell its expiry
+func (a *OAuth2Authenticator) fetchToken(ctx context.Context) (string, time.Time, error) {
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.config.Scopes, " "))
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+
+	issued := a.now()
+	response, err := a.config.HTTPClient.Do(request)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer drainAndClose(response.Body)
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token := tokenResponse{}
+	jsonErr := json.Unmarshal(body, &token)
+
+	if response.StatusCode != http.StatusOK {
+		return "", time.Time{}, &TokenError{StatusCode: response.StatusCode, ErrorCode: token.Error, Description: token.ErrorDescription}
+	}
+	if jsonErr != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %v", jsonErr)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("invalid token response: no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("invalid token response: unsupported token type %q", token.TokenType)
+	}
+
+	renewAt := time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		delta := a.config.ExpiryDelta
+		if delta > lifetime/2 {
+			delta = lifetime / 2
+		}
+		renewAt = issued.Add(lifetime - delta)
+	}
+	return token.AccessToken, renewAt, nil
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTokenServer starts a fake token endpoint that issues the tokens token-1,
+// token-2... valid for expiresIn seconds, and counts the token requests
+func newTokenServer(t *testing.T, expiresIn int, delay time.Duration) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		number := atomic.AddInt32(&requests, 1)
+		clientID, secret, _ := r.BasicAuth()
+		assert.EqualValues(t, "client-1", clientID)
+		assert.EqualValues(t, "secret", secret)
+		assert.EqualValues(t, "client_credentials", r.PostFormValue("grant_type"))
+		assert.EqualValues(t, "accounts:read accounts:write", r.PostFormValue("scope"))
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, number, expiresIn)
+	}))
+	return server, &requests
+}
+
+// testOAuth2Config returns the OAuth2Config of a fake token endpoint
+func testOAuth2Config(tokenURL string) OAuth2Config {
+	return OAuth2Config{TokenURL: tokenURL, ClientID: "client-1", ClientSecret: "secret",
+		Scopes: []string{"accounts:read", "accounts:write"}}
+}
+
+func TestOAuth2Authenticator_shouldCacheTheToken(t *testing.T) {
+	// prepare
+	tokenServer, tokenRequests := newTokenServer(t, 3600, 0)
+	defer tokenServer.Close()
+	authenticator := NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))
+
+	// test
+	for i := 0; i < 3; i++ {
+		request, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/v1/organisation/accounts", nil)
+		assert.Nil(t, authenticator.Authenticate(request))
+
+		// validate
+		assert.EqualValues(t, "Bearer token-1", request.Header.Get("Authorization"))
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(tokenRequests))
+}
+
+func TestOAuth2Authenticator_shouldRenewTheTokenBeforeItExpires(t *testing.T) {
+	// prepare
+	tokenServer, _ := newTokenServer(t, 60, 0)
+	defer tokenServer.Close()
+	authenticator := NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))
+	now := time.Now()
+	authenticator.now = func() time.Time { return now }
+
+	// test & validate
+	token, err := authenticator.Token(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, "token-1", token)
+
+	now = now.Add(29 * time.Second)
+	token, _ = authenticator.Token(context.Background())
+	assert.EqualValues(t, "token-1", token)
+
+	// within the default expiry delta of 30 seconds
+	now = now.Add(2 * time.Second)
+	token, _ = authenticator.Token(context.Background())
+	assert.EqualValues(t, "token-2", token)
+}
+
+func TestOAuth2Authenticator_withShortLivedToken_shouldCacheItForHalfItsLifetime(t *testing.T) {
+	// prepare
+	tokenServer, tokenRequests := newTokenServer(t, 20, 0)
+	defer tokenServer.Close()
+	authenticator := NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))
+	now := time.Now()
+	authenticator.now = func() time.Time { return now }
+
+	// test & validate
+	for i := 0; i < 5; i++ {
+		token, err := authenticator.Token(context.Background())
+		assert.Nil(t, err)
+		assert.EqualValues(t, "token-1", token)
+		now = now.Add(time.Second)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(tokenRequests))
+
+	// past half the lifetime of 20 seconds
+	now = now.Add(6 * time.Second)
+	token, _ := authenticator.Token(context.Background())
+	assert.EqualValues(t, "token-2", token)
+}
+
+func TestOAuth2Authenticator_shouldFetchOneTokenForConcurrentRequests(t *testing.T) {
+	// prepare
+	tokenServer, tokenRequests := newTokenServer(t, 3600, 50*time.Millisecond)
+	defer tokenServer.Close()
+	authenticator := NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))
+
+	// test
+	tokens := make([]string, 20)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = authenticator.Token(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	// validate
+	assert.EqualValues(t, 1, atomic.LoadInt32(tokenRequests))
+	for _, token := range tokens {
+		assert.EqualValues(t, "token-1", token)
+	}
+}
+
+func TestOAuth2Authenticator_shouldRetryOnceWithANewToken(t *testing.T) {
+	// prepare
+	restoreInits()
+	tokenServer, tokenRequests := newTokenServer(t, 3600, 0)
+	defer tokenServer.Close()
+
+	var authorizations []string
+	accepted := "Bearer token-2"
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != accepted {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"0673746b-8dd3-4bd2-b398-941bdf2865df"}}`))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithRetryPolicy(NoRetry),
+		WithAuthenticator(NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))))
+
+	// test
+	account, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// validate
+	assert.Nil(t, err)
+	assert.NotNil(t, account)
+	assert.EqualValues(t, []string{"Bearer token-1", "Bearer token-2"}, authorizations)
+	assert.EqualValues(t, 2, atomic.LoadInt32(tokenRequests))
+
+	// test: a token that keeps being refused is retried once only
+	authorizations = nil
+	accepted = "none"
+	_, err = c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// validate
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, http.StatusUnauthorized, apiError.StatusCode)
+	assert.EqualValues(t, []string{"Bearer token-2", "Bearer token-3"}, authorizations)
+}
+
+func TestOAuth2Authenticator_whenTheTokenIsRefused_shouldNotSendTheRequest(t *testing.T) {
+	// prepare
+	restoreInits()
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_client","error_description":"unknown client"}`))
+	}))
+	defer tokenServer.Close()
+
+	requests := 0
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithAuthenticator(NewOAuth2Authenticator(testOAuth2Config(tokenServer.URL))))
+
+	// test
+	_, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// validate
+	var tokenError *TokenError
+	assert.True(t, errors.As(err, &tokenError))
+	assert.EqualValues(t, &TokenError{StatusCode: http.StatusBadRequest, ErrorCode: "invalid_client", Description: "unknown client"}, tokenError)
+	assert.EqualValues(t, "token request failed with status 400: invalid_client: unknown client", err.Error())
+	assert.EqualValues(t, 0, requests)
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// optionalState tells whether an optional attribute is absent, null or set
+type optionalState uint8
+
+const (
+	absent optionalState = iota
+	null
+	set
+)
+
+// optional holds the state of an optional attribute. Its zero value is absent.
+type optional struct {
+	state optionalState
+}
+
+// IsAbsent reports whether the attribute is left out of the json
+func (o optional) IsAbsent() bool {
+	return o.state == absent
+}
+
+// IsNull reports whether the attribute is sent, or was received, as null
+func (o optional) IsNull() bool {
+	return o.state == null
+}
+
+// IsSet reports whether the attribute has a value
+func (o optional) IsSet() bool {
+	return o.state == set
+}
+
+// marshal returns the json of value when set, null otherwise. An absent
+// attribute is left out by the jsonObject of the struct that holds it.
+func (o optional) marshal(value interface{}) ([]byte, error) {
+	if o.state != set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(value)
+}
+
+// unmarshal decodes data into value, or marks the attribute null
+func (o *optional) unmarshal(data []byte, value interface{}) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.state = null
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return err
+	}
+	o.state = set
+	return nil
+}
+
+// OptionalString is a string attribute that is absent, null or set. Its zero
+// value is absent.
+type OptionalString struct {
+	optional
+	value string
+}
+
+// String returns an OptionalString set to value
+func String(value string) OptionalString {
+	return OptionalString{optional{set}, value}
+}
+
+// NullString returns an OptionalString that is null
+func NullString() OptionalString {
+	return OptionalString{optional: optional{null}}
+}
+
+// Value returns the value, "" unless the attribute is set
+func (o OptionalString) Value() string {
+	return o.value
+}
+
+func (o OptionalString) String() string {
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler
+func (o OptionalString) MarshalJSON() ([]byte, error) {
+	return o.marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.value = ""
+	return o.unmarshal(data, &o.value)
+}
+
+// OptionalBool is a bool attribute that is absent, null or set. Its zero
+// value is absent.
+type OptionalBool struct {
+	optional
+	value bool
+}
+
+// Bool returns an OptionalBool set to value
+func Bool(value bool) OptionalBool {
+	return OptionalBool{optional{set}, value}
+}
+
+// NullBool returns an OptionalBool that is null
+func NullBool() OptionalBool {
+	return OptionalBool{optional: optional{null}}
+}
+
+// Value returns the value, false unless the attribute is set
+func (o OptionalBool) Value() bool {
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler
+func (o OptionalBool) MarshalJSON() ([]byte, error) {
+	return o.marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *OptionalBool) UnmarshalJSON(data []byte) error {
+	o.value = false
+	return o.unmarshal(data, &o.value)
+}
+
+// OptionalStrings is a string list attribute that is absent, null or set. Its
+// zero value is absent.
+type OptionalStrings struct {
+	optional
+	value []string
+}
+
+// Strings returns an OptionalStrings set to values. Without values it is set
+// to an empty list.
+func Strings(values ...string) OptionalStrings {
+	if values == nil {
+		values = []string{}
+	}
+	return OptionalStrings{optional{set}, values}
+}
+
+// NullStrings returns an OptionalStrings that is null
+func NullStrings() OptionalStrings {
+	return OptionalStrings{optional: optional{null}}
+}
+
+// Value returns the values, nil unless the attribute is set
+func (o OptionalStrings) Value() []string {
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler
+func (o OptionalStrings) MarshalJSON() ([]byte, error) {
+	return o.marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *OptionalStrings) UnmarshalJSON(data []byte) error {
+	o.value = nil
+	return o.unmarshal(data, &o.value)
+}
+
+// absentable is implemented by the optional attribute types
+type absentable interface {
+	IsAbsent() bool
+}
+
+// jsonObject builds a json object member by member, in order, leaving out
+// the absent optional attributes. It is used by the MarshalJSON of the
+// structs holding optional attributes, since omitempty does not apply to
+// struct fields.
+type jsonObject struct {
+	names  []string
+	values []interface{}
+}
+
+// add adds a member, unless value is an absent optional attribute
+func (o *jsonObject) add(name string, value interface{}) {
+	if a, ok := value.(absentable); ok && a.IsAbsent() {
+		return
+	}
+	o.names = append(o.names, name)
+	o.values = append(o.values, value)
+}
+
+// MarshalJSON implements json.Marshaler
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('{')
+	for i, name := range o.names {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		nameBytes, _ := json.Marshal(name)
+		buffer.Write(nameBytes)
+		buffer.WriteByte(':')
+		valueBytes, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(valueBytes)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
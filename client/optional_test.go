@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountAttributes_marshalsAbsentNullAndSet(t *testing.T) {
+	// prepare
+	attributes := AccountAttributes{
+		Country:      "GB",
+		Name:         []string{"Samantha Holder"},
+		Bic:          String(""),
+		JointAccount: Bool(false),
+		Iban:         NullString(),
+	}
+
+	// test
+	jsonBytes, err := json.Marshal(attributes)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"country":"GB","bic":"","iban":null,"name":["Samantha Holder"],"joint_account":false}`, string(jsonBytes))
+}
+
+func TestAccountAttributes_unmarshalsAbsentNullAndSet(t *testing.T) {
+	// test
+	attributes := AccountAttributes{}
+	err := json.Unmarshal([]byte(`{"country":"GB","bic":"","iban":null,"joint_account":false,"alternative_names":[]}`), &attributes)
+
+	// validate
+	assert.Nil(t, err)
+	assert.True(t, attributes.Bic.IsSet())
+	assert.EqualValues(t, "", attributes.Bic.Value())
+	assert.True(t, attributes.Iban.IsNull())
+	assert.True(t, attributes.JointAccount.IsSet())
+	assert.False(t, attributes.JointAccount.Value())
+	assert.True(t, attributes.AlternativeNames.IsSet())
+	assert.EqualValues(t, []string{}, attributes.AlternativeNames.Value())
+	assert.True(t, attributes.BankID.IsAbsent())
+	assert.True(t, attributes.Switched.IsAbsent())
+
+	jsonBytes, _ := json.Marshal(attributes)
+	assert.EqualValues(t, `{"country":"GB","bic":"","iban":null,"name":null,"alternative_names":[],"joint_account":false}`, string(jsonBytes))
+}
+
+func TestCreateRequestBody_shouldNotSendAttributesThatWereNotProvided(t *testing.T) {
+	// prepare
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// test
+	jsonBytes, _ := json.Marshal(account.Data.Attributes)
+	attributes := map[string]interface{}{}
+	err := json.Unmarshal(jsonBytes, &attributes)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "NWBKGB22", attributes["bic"])
+	assert.NotContains(t, attributes, "joint_account")
+	assert.NotContains(t, attributes, "account_matching_opt_out")
+	assert.NotContains(t, attributes, "iban")
+}
+
+func TestOptional_constructors(t *testing.T) {
+	assert.True(t, OptionalString{}.IsAbsent())
+	assert.True(t, NullString().IsNull())
+	assert.EqualValues(t, "GBP", String("GBP").Value())
+	assert.EqualValues(t, "GBP", String("GBP").String())
+	assert.True(t, OptionalBool{}.IsAbsent())
+	assert.True(t, NullBool().IsNull())
+	assert.True(t, Bool(true).Value())
+	assert.True(t, NullStrings().IsNull())
+	assert.EqualValues(t, []string{}, Strings().Value())
+	assert.True(t, Strings().IsSet())
+
+	var b OptionalBool
+	assert.NotNil(t, json.Unmarshal([]byte(`"yes"`), &b))
+	assert.False(t, b.IsSet())
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Operation identifies an account operation, e.g. for per operation rate limits
+type Operation string
+
+// The account operations of the Client
+const (
+	OperationCreate Operation = "create"
+	OperationFetch  Operation = "fetch"
+	OperationList   Operation = "list"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+// RateLimiter is a token bucket that limits the rate of requests. It is safe
+// for concurrent use and can be shared by several Clients using the same
+// credentials. Callers are served in the order they called Wait, so a noisy
+// goroutine cannot starve the others.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that allows requestsPerSecond requests
+// on average and bursts of up to burst requests. A burst below 1 is treated as
+// 1. A requestsPerSecond that is not positive is refused, as such a limiter
+// would never let a request through once the burst is used.
+func NewRateLimiter(requestsPerSecond float64, burst int) (*RateLimiter, error) {
+	if !(requestsPerSecond > 0) {
+		return nil, fmt.Errorf("rate limiter: the rate must be positive, got %v", requestsPerSecond)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}, nil
+}
+
+// WithRateLimiter limits the rate of all requests of the Client
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// WithOperationRateLimiter limits the rate of the requests of one operation of
+// the Client, in addition to the limiter set with WithRateLimiter
+func WithOperationRateLimiter(operation Operation, limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.operationRateLimiters[operation] = limiter
+	}
+}
+
+// Wait blocks until the request may be sent or ctx is done. It fails at once,
+// without waiting, when the wait would exceed the deadline of ctx.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// take a token, possibly from the future; the negative balance queues the
+	// callers in the order they arrived
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.cancel()
+		return fmt.Errorf("rate limiter wait of %s would exceed the context deadline: %w", wait, context.DeadlineExceeded)
+	}
+
+	if err := sleep(ctx, wait); err != nil {
+		l.cancel()
+		return err
+	}
+	return nil
+}
+
+// refill adds the tokens accumulated since the last call, up to the burst
+func (l *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = l.tokens + elapsed.Seconds()*l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// cancel gives back the token of a caller that stopped waiting
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// waitRateLimiters blocks until the rate limiters of the Client allow a
+// request of the operation. When the limiter of the operation fails, the
+// token taken from the limiter of the Client is given back.
+func (c *Client) waitRateLimiters(ctx context.Context, operation Operation) error {
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if limiter := c.operationRateLimiters[operation]; limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			if c.rateLimiter != nil {
+				c.rateLimiter.cancel()
+			}
+			return err
+		}
+	}
+	return nil
+}
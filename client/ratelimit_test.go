@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_allowsBurstThenLimitsRate(t *testing.T) {
+	// prepare
+	limiter, _ := NewRateLimiter(20, 2)
+	ctx := context.Background()
+
+	// test
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, limiter.Wait(ctx))
+	}
+	burst := time.Since(start)
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, limiter.Wait(ctx))
+	}
+	total := time.Since(start)
+
+	// validate
+	assert.True(t, burst < 20*time.Millisecond, burst)
+	assert.True(t, total >= 90*time.Millisecond, total)
+}
+
+func TestRateLimiter_whenWaitExceedsDeadline_shouldFailAtOnceAndGiveBackToken(t *testing.T) {
+	// prepare
+	limiter, _ := NewRateLimiter(1, 1)
+	assert.Nil(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// test
+	start := time.Now()
+	err := limiter.Wait(ctx)
+
+	// validate
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+	assert.True(t, limiter.tokens > -0.5, limiter.tokens)
+}
+
+func TestRateLimiter_whenContextIsCancelled_shouldStopWaiting(t *testing.T) {
+	// prepare
+	limiter, _ := NewRateLimiter(0.1, 1)
+	assert.Nil(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	// test
+	err := limiter.Wait(ctx)
+
+	// validate
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestNewRateLimiter_withoutRate_shouldFail(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		// test
+		limiter, err := NewRateLimiter(rate, 10)
+
+		// validate
+		assert.Nil(t, limiter, rate)
+		assert.NotNil(t, err, rate)
+	}
+}
+
+func TestRateLimiter_sharedAcrossGoroutines(t *testing.T) {
+	// prepare
+	limiter, _ := NewRateLimiter(100, 1)
+	goroutines := 10
+
+	// test
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, limiter.Wait(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	// validate
+	assert.True(t, time.Since(start) >= 80*time.Millisecond)
+}
+
+func TestNewClient_withOperationRateLimiter_shouldOnlyLimitThatOperation(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[]}`))
+	})
+	defer server.Close()
+
+	fetchLimiter, _ := NewRateLimiter(0.01, 1)
+	c, _ := NewClient(server.URL, WithOperationRateLimiter(OperationFetch, fetchLimiter))
+	assert.Nil(t, fetchLimiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// test
+	_, listErr := c.List(ctx, nil)
+	_, fetchErr := c.Fetch(ctx, guuid.New().String())
+
+	// validate
+	assert.Nil(t, listErr)
+	assert.True(t, errors.Is(fetchErr, context.DeadlineExceeded))
+}
+
+func TestNewClient_whenOperationRateLimiterFails_shouldGiveBackTheClientToken(t *testing.T) {
+	// prepare
+	limiter, _ := NewRateLimiter(1, 2)
+	fetchLimiter, _ := NewRateLimiter(0.01, 1)
+	assert.Nil(t, fetchLimiter.Wait(context.Background()))
+	c, _ := NewClient("http://localhost:8080", WithRateLimiter(limiter),
+		WithOperationRateLimiter(OperationFetch, fetchLimiter))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// test
+	_, err := c.Fetch(ctx, guuid.New().String())
+
+	// validate
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, limiter.tokens > 1.5, limiter.tokens)
+}
+
+func TestNewClient_withRateLimiter_shouldLimitRetriesToo(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	limiter, _ := NewRateLimiter(20, 1)
+	c, _ := NewClient(server.URL, WithRateLimiter(limiter),
+		WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	start := time.Now()
+	_, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	assert.True(t, IsRetryable(err))
+	assert.EqualValues(t, 3, len(attempts))
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+}
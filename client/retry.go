@@ -0,0 +1,363 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures how the Client retries requests that failed with a
+// transient error. Requests with a safe method (GET, HEAD, OPTIONS) are
+// retried on a transport error or one of the RetryStatusCodes. Other requests,
+// e.g. the POST of CreateAccount and the DELETE of DeleteAccount, are only
+// retried when it is certain that the request never reached the form3 api.
+//
+// A rate limited request, answered with 429 or with 503 and a Retry-After
+// header, was not processed by the form3 api and is retried whatever its
+// method, after the wait the api asked for. These retries are bounded by
+// MaxRateLimitWaits and MaxRetryAfter, not by MaxAttempts.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	// A value below 2 disables retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry. It doubles on every retry.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, by which the wait is randomised
+	Jitter float64
+	// RetryStatusCodes are the response statuses that are retried
+	RetryStatusCodes []int
+	// MaxRetryAfter caps the Retry-After wait of a rate limited request. When
+	// the api asks for a longer wait a RateLimitError is returned instead.
+	// 0 means no cap.
+	MaxRetryAfter time.Duration
+	// MaxRateLimitWaits is the maximum number of times a rate limited request
+	// waits and is sent again. It is counted apart from MaxAttempts, so that a
+	// policy without retries still honours the rate limiting of the api.
+	// 0 means DefaultMaxRateLimitWaits, a negative value disables the waits.
+	MaxRateLimitWaits int
+	// OnAttempt is called after every attempt when it is set
+	OnAttempt func(Attempt)
+}
+
+// Attempt describes the outcome of a single attempt of a request
+type Attempt struct {
+	Method string
+	Path   string
+	// Number is 1 for the first attempt
+	Number int
+	// StatusCode is 0 when no response was received
+	StatusCode int
+	Err        error
+	// Retry reports whether another attempt follows, after Wait
+	Retry bool
+	Wait  time.Duration
+}
+
+// DefaultMaxRateLimitWaits is the number of times a rate limited request
+// waits and is sent again unless the RetryPolicy says otherwise
+const DefaultMaxRateLimitWaits = 5
+
+// minRateLimitWait is the wait of a rate limited request when neither the api
+// nor the backoff of the RetryPolicy tell one
+const minRateLimitWait = time.Second
+
+// NoRetry is a RetryPolicy that sends every request once, unless the form3
+// api rate limits it: it is then sent again after the wait the api asks for,
+// up to a minute
+var NoRetry = RetryPolicy{MaxAttempts: 1, MaxRetryAfter: time.Minute}
+
+// DefaultRetryPolicy returns the RetryPolicy of a Client created with NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Jitter:      0.2,
+		RetryStatusCodes: []int{http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		MaxRetryAfter: time.Minute,
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy of the Client
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// RetryError is returned when a request failed with a transport error on
+// every one of its attempts
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("giving up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// attemptKey is the context key of the attempt number of a request
+type attemptKey struct{}
+
+// attemptNumber returns the attempt number stored in ctx by the Client, or 0
+func attemptNumber(ctx context.Context) int {
+	number, _ := ctx.Value(attemptKey{}).(int)
+	return number
+}
+
+// do sends the request of the operation with the http.Client of the Client
+// and retries it according to the RetryPolicy of the Client. Every attempt
+// waits for the rate limiters of the Client and is authenticated by its
+// Authenticator.
+func (c *Client) do(operation Operation, request *http.Request) (*http.Response, error) {
+
+	policy := c.retryPolicy
+	ctx := request.Context()
+	// reauthenticated is 1 once the request was sent again with new
+	// credentials and rateLimitWaits counts the rate limited attempts,
+	// attempts that MaxAttempts does not count
+	reauthenticated := 0
+	rateLimitWaits := 0
+
+	for number := 1; ; number++ {
+
+		if err := c.waitRateLimiters(ctx, operation); err != nil {
+			return nil, err
+		}
+
+		attemptRequest := request.Clone(context.WithValue(ctx, attemptKey{}, number))
+		if number > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptRequest.Body = body
+		}
+		if c.authenticator != nil {
+			if err := c.authenticator.Authenticate(attemptRequest); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := c.httpClient.Do(attemptRequest)
+
+		attempt := Attempt{Method: request.Method, Path: request.URL.Path, Number: number, Err: err}
+		if response != nil {
+			attempt.StatusCode = response.StatusCode
+		}
+		rateLimited := err == nil && isRateLimited(response)
+		if reauthenticated == 0 && c.reauthenticate(attemptRequest, response) {
+			reauthenticated = 1
+			attempt.Retry = true
+		} else if rateLimited {
+			attempt.Retry = rateLimitWaits < policy.maxRateLimitWaits() && canReplay(request) && ctx.Err() == nil
+			if attempt.Retry {
+				rateLimitWaits++
+				attempt.Wait = policy.backoff(rateLimitWaits)
+				if attempt.Wait <= 0 {
+					attempt.Wait = minRateLimitWait
+				}
+			}
+		} else {
+			attempt.Retry = number-reauthenticated-rateLimitWaits < policy.MaxAttempts && canReplay(request) && policy.retryable(attemptRequest, response, err)
+			if attempt.Retry {
+				attempt.Wait = policy.backoff(number - reauthenticated - rateLimitWaits)
+			}
+		}
+
+		var rateLimitError *RateLimitError
+		if attempt.Retry && rateLimited {
+			if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+				attempt.Wait = retryAfter
+			}
+			if !policy.canWait(ctx, attempt.Wait) {
+				attempt.Retry = false
+				rateLimitError = &RateLimitError{APIError: newAPIError(response), RetryAfter: attempt.Wait}
+				drainAndClose(response.Body)
+			}
+		}
+
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(attempt)
+		}
+
+		if rateLimitError != nil {
+			return nil, rateLimitError
+		}
+
+		if !attempt.Retry {
+			if err != nil && number > 1 {
+				return nil, &RetryError{Attempts: number, Err: err}
+			}
+			return response, err
+		}
+
+		if response != nil {
+			drainAndClose(response.Body)
+		}
+		if err := sleep(ctx, attempt.Wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether the policy allows to retry the attempt that ended
+// with the given response or error. Rate limited responses are left to do.
+func (p *RetryPolicy) retryable(request *http.Request, response *http.Response, err error) bool {
+
+	if request.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if isSafeMethod(request.Method) {
+			return isTransientError(err)
+		}
+		return notSent(err)
+	}
+
+	if !isSafeMethod(request.Method) {
+		return false
+	}
+	for _, statusCode := range p.RetryStatusCodes {
+		if response.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait after the given attempt: BaseBackoff doubled for
+// every previous retry, randomised by Jitter and capped at MaxBackoff
+func (p *RetryPolicy) backoff(number int) time.Duration {
+
+	wait := p.BaseBackoff
+	for i := 1; i < number && (p.MaxBackoff == 0 || wait < p.MaxBackoff); i++ {
+		wait = wait * 2
+	}
+	if p.Jitter > 0 {
+		wait = wait + time.Duration((rand.Float64()*2-1)*p.Jitter*float64(wait))
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// maxRateLimitWaits returns MaxRateLimitWaits, or its default
+func (p *RetryPolicy) maxRateLimitWaits() int {
+	if p.MaxRateLimitWaits == 0 {
+		return DefaultMaxRateLimitWaits
+	}
+	return p.MaxRateLimitWaits
+}
+
+// canWait reports whether the policy allows to wait for a rate limited request
+// and the wait ends before the deadline of ctx
+func (p *RetryPolicy) canWait(ctx context.Context, wait time.Duration) bool {
+
+	if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Now().Add(wait).Before(deadline)
+}
+
+// isRateLimited reports whether the form3 api refused to process the request
+// because of rate limiting, i.e. it responded with 429, or with 503 and a
+// Retry-After header
+func isRateLimited(response *http.Response) bool {
+	return response.StatusCode == http.StatusTooManyRequests ||
+		(response.StatusCode == http.StatusServiceUnavailable && response.Header.Get("Retry-After") != "")
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an http date, into the wait from now. A date in the past
+// gives a wait of 0.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// canReplay reports whether the body of the request can be sent again
+func canReplay(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+// isSafeMethod reports whether a request with the method has no side effects
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// isTransientError reports whether a transport error is likely to go away on retry
+func isTransientError(err error) bool {
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
+
+// notSent reports whether a transport error happened before the request was
+// written to a connection, i.e. while dialing the form3 api
+func notSent(err error) bool {
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Op == "dial"
+}
+
+// drainAndClose reads the rest of the body so that the connection can be reused,
+// and closes it
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, maxErrorBodySize))
+	body.Close()
+}
+
+// sleep waits for the given duration or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
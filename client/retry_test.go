@@ -0,0 +1,464 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	guuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastRetryPolicy returns a RetryPolicy with short waits that records the attempts
+func fastRetryPolicy(attempts *[]Attempt) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	policy.OnAttempt = func(attempt Attempt) {
+		*attempts = append(*attempts, attempt)
+	}
+	return policy
+}
+
+func TestFetch_whenForm3ApiReturns503ThenSucceeds_shouldRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"type":"accounts","id":"` + accountID + `"}}`))
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	account, err := c.Fetch(context.Background(), accountID)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, accountID, account.ID)
+	assert.EqualValues(t, 3, len(attempts))
+	assert.EqualValues(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+	assert.True(t, attempts[0].Retry)
+	assert.True(t, attempts[1].Retry)
+	assert.False(t, attempts[2].Retry)
+	assert.EqualValues(t, http.StatusOK, attempts[2].StatusCode)
+}
+
+func TestFetch_whenForm3ApiKeepsFailing_shouldReturnAPIErrorWithAttempts(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	_, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.EqualValues(t, 3, apiError.Attempts)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.EqualValues(t, 3, len(attempts))
+}
+
+func TestCreate_whenForm3ApiReturns500_shouldNotRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	uri := "/v1/organisation/accounts"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	_, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.True(t, IsRetryable(err))
+	assert.EqualValues(t, 1, requests)
+	assert.EqualValues(t, 1, len(attempts))
+}
+
+func TestCreate_whenConnectionIsRefused_shouldRetryAndReturnRetryError(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	_, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	var retryError *RetryError
+	assert.True(t, errors.As(err, &retryError))
+	assert.EqualValues(t, 3, retryError.Attempts)
+	assert.EqualValues(t, 3, len(attempts))
+	assert.EqualValues(t, 0, attempts[0].StatusCode)
+}
+
+func TestFetch_whenConnectionIsReset_shouldRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"type":"accounts","id":"` + accountID + `"}}`))
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	account, err := c.Fetch(context.Background(), accountID)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, accountID, account.ID)
+	assert.EqualValues(t, 2, len(attempts))
+	assert.NotNil(t, attempts[0].Err)
+}
+
+func TestFetch_whenContextIsCancelledDuringBackoff_shouldStop(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Minute
+	c, _ := NewClient(server.URL, WithRetryPolicy(policy))
+
+	// test
+	_, err := c.Fetch(ctx, guuid.New().String())
+
+	// validate
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestFetch_withNoRetry_shouldSendOnce(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithRetryPolicy(NoRetry))
+
+	// test
+	_, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	assert.True(t, IsRetryable(err))
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.EqualValues(t, 100*time.Millisecond, policy.backoff(1))
+	assert.EqualValues(t, 200*time.Millisecond, policy.backoff(2))
+	assert.EqualValues(t, 400*time.Millisecond, policy.backoff(3))
+	assert.EqualValues(t, time.Second, policy.backoff(5))
+	assert.EqualValues(t, time.Second, policy.backoff(50))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := policy.backoff(2)
+		assert.True(t, wait >= 100*time.Millisecond && wait <= 300*time.Millisecond, wait)
+	}
+}
+
+func TestFetch_whenForm3ApiReturns429WithRetryAfterSeconds_shouldWaitAndRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"type":"accounts","id":"` + accountID + `"}}`))
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	start := time.Now()
+	account, err := c.Fetch(context.Background(), accountID)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, accountID, account.ID)
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.EqualValues(t, time.Second, attempts[0].Wait)
+}
+
+func TestCreate_whenForm3ApiReturns429_shouldRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	account := CreateRequestBody(guuid.New().String(), guuid.New().String())
+	uri := "/v1/organisation/accounts"
+
+	var bodies []string
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	c, _ := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(&attempts)))
+
+	// test
+	created, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, account.Data.ID, created.ID)
+	assert.EqualValues(t, 2, len(bodies))
+	assert.EqualValues(t, bodies[0], bodies[1])
+	assert.EqualValues(t, 0, attempts[0].Wait)
+}
+
+func TestFetch_whenRetryAfterExceedsDeadline_shouldReturnRateLimitError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error_message":"service is busy"}`))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// test
+	start := time.Now()
+	_, err := c.Fetch(ctx, guuid.New().String())
+
+	// validate
+	var rateLimitError *RateLimitError
+	assert.True(t, errors.As(err, &rateLimitError))
+	assert.EqualValues(t, 30*time.Second, rateLimitError.RetryAfter)
+	assert.EqualValues(t, "service is busy", rateLimitError.ErrorMessage)
+	assert.True(t, IsRateLimited(err))
+	assert.True(t, IsRetryable(err))
+	assert.EqualValues(t, 1, requests)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestFetch_whenRateLimitedOnEveryAttempt_shouldReturnRateLimitError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts/"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer server.Close()
+
+	var attempts []Attempt
+	policy := fastRetryPolicy(&attempts)
+	policy.MaxRateLimitWaits = 2
+	c, _ := NewClient(server.URL, WithRetryPolicy(policy))
+
+	// test
+	_, err := c.Fetch(context.Background(), guuid.New().String())
+
+	// validate
+	var rateLimitError *RateLimitError
+	assert.True(t, errors.As(err, &rateLimitError))
+	assert.EqualValues(t, 3, rateLimitError.Attempts)
+	assert.EqualValues(t, 3, len(attempts))
+}
+
+func TestGatherAccountsWithContext_whenRateLimited_shouldGatherAllPages(t *testing.T) {
+	// prepare
+	restoreInits()
+	pageSize := 2
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(1)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	accounts, err := c.GatherAccountsWithContext(context.Background(), pageSize)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, len(accounts))
+	assert.EqualValues(t, 3, requests)
+}
+
+func TestFetch_withNoRetry_whenRateLimited_shouldWaitAndRetry(t *testing.T) {
+	// prepare
+	restoreInits()
+	accountID := guuid.New().String()
+	uri := "/v1/organisation/accounts/"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"type":"accounts","id":"` + accountID + `"}}`))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithRetryPolicy(NoRetry))
+
+	// test
+	account, err := c.Fetch(context.Background(), accountID)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, accountID, account.ID)
+	assert.EqualValues(t, 4, requests)
+}
+
+func TestGatherAccounts_whenRateLimited_shouldGatherAllPages(t *testing.T) {
+	// prepare
+	restoreInits()
+	pageSize := 2
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(1)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+
+	// test
+	accounts := GatherAccounts(server.URL, pageSize)
+
+	// validate
+	assert.EqualValues(t, 5, len(accounts))
+	assert.EqualValues(t, 4, requests)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("120", now)
+	assert.True(t, ok)
+	assert.EqualValues(t, 2*time.Minute, wait)
+
+	wait, ok = parseRetryAfter("Tue, 05 Jan 2021 10:00:30 GMT", now)
+	assert.True(t, ok)
+	assert.EqualValues(t, 30*time.Second, wait)
+
+	wait, ok = parseRetryAfter("Tue, 05 Jan 2021 09:00:00 GMT", now)
+	assert.True(t, ok)
+	assert.EqualValues(t, 0, wait)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("-1", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,355 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureAlgorithm is the algorithm of the HTTP Signatures of the form3 api
+const SignatureAlgorithm = "rsa-sha256"
+
+// DefaultMaxClockSkew is the difference between the Date of a signed request
+// and the clock of a Verifier that is accepted unless configured otherwise
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// requestTarget is the pseudo header of the method and path of the request
+const requestTarget = "(request-target)"
+
+// ErrInvalidSignature is returned by Verifier.Verify for a request whose
+// signature is missing, malformed or does not match
+var ErrInvalidSignature = errors.New("invalid http signature")
+
+// Signer is an Authenticator that signs requests with the HTTP Signatures the
+// form3 api requires: it adds the Date header and, for a request with a body,
+// the SHA-256 Digest of the body, then signs the (request-target), host,
+// date, accept, digest, content-type and content-length of the request with
+// an RSA key and adds the signature as the Authorization header. Headers the
+// request does not have are left out of the signature.
+type Signer struct {
+	keyID string
+	key   *rsa.PrivateKey
+	now   func() time.Time
+}
+
+// NewSigner creates a Signer that signs with the given private key. keyID is
+// the id of the public key registered with the form3 api.
+func NewSigner(keyID string, key *rsa.PrivateKey) *Signer {
+	return &Signer{keyID: keyID, key: key, now: time.Now}
+}
+
+// NewSignerFromFile creates a Signer with the private key of a PEM file
+func NewSignerFromFile(keyID, path string) (*Signer, error) {
+
+	key, err := LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(keyID, key), nil
+}
+
+// Authenticate implements Authenticator
+func (s *Signer) Authenticate(request *http.Request) error {
+
+	body, err := requestBody(request)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+	if body != nil {
+		request.Header.Set("Digest", digest(body))
+	}
+
+	headers := signedHeaders(request)
+	hashed := sha256.Sum256([]byte(signingString(request, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf(`Signature keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyID, SignatureAlgorithm, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// Verifier checks the HTTP Signatures of requests, like the form3 api does.
+// It is meant for tests and local fakes of the form3 api.
+type Verifier struct {
+	// Keys are the public keys by key id
+	Keys map[string]*rsa.PublicKey
+	// MaxClockSkew is the accepted difference between the Date of a request
+	// and the clock of the Verifier. 0 means DefaultMaxClockSkew.
+	MaxClockSkew time.Duration
+}
+
+// Verify returns nil when the request carries a valid signature of one of the
+// Keys, over at least its (request-target), host and date and, when it has a
+// body, its digest, which must match the body
+func (v *Verifier) Verify(request *http.Request) error {
+
+	params, err := parseSignature(request.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+	key, ok := v.Keys[params["keyId"]]
+	if !ok {
+		return fmt.Errorf("%w: unknown key id %q", ErrInvalidSignature, params["keyId"])
+	}
+	if params["algorithm"] != SignatureAlgorithm {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, params["algorithm"])
+	}
+
+	body, err := requestBody(request)
+	if err != nil {
+		return err
+	}
+	headers := strings.Fields(params["headers"])
+	required := []string{requestTarget, "host", "date"}
+	if body != nil {
+		required = append(required, "digest")
+		if request.Header.Get("Digest") != digest(body) {
+			return fmt.Errorf("%w: digest does not match the body", ErrInvalidSignature)
+		}
+	}
+	for _, name := range required {
+		if !containsString(headers, name) {
+			return fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, name)
+		}
+	}
+
+	if err := v.checkDate(request.Header.Get("Date")); err != nil {
+		return err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	hashed := sha256.Sum256([]byte(signingString(request, headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// Handler returns a handler that answers a request without a valid signature
+// with 401 Unauthorized, in the json of the form3 api errors, and passes the
+// others to next
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error_message":%q}`, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkDate returns an error when the Date of a request is missing or too far
+// from now
+func (v *Verifier) checkDate(value string) error {
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return fmt.Errorf("%w: invalid date %q", ErrInvalidSignature, value)
+	}
+	maxClockSkew := v.MaxClockSkew
+	if maxClockSkew == 0 {
+		maxClockSkew = DefaultMaxClockSkew
+	}
+	if skew := time.Since(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("%w: date %q is too far from now", ErrInvalidSignature, value)
+	}
+	return nil
+}
+
+// signedHeaders returns the names of the headers of the request that are signed
+func signedHeaders(request *http.Request) []string {
+
+	headers := []string{requestTarget, "host", "date"}
+	for _, name := range []string{"accept", "digest", "content-type", "content-length"} {
+		if headerValue(request, name) != "" {
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// signingString returns the string that is signed: one "name: value" line per
+// signed header
+func signingString(request *http.Request, headers []string) string {
+
+	lines := make([]string, len(headers))
+	for i, name := range headers {
+		lines[i] = name + ": " + headerValue(request, name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headerValue returns the value of a signed header. The host and the content
+// length are not in the headers of an outgoing request, and are taken from
+// the request itself on both sides.
+func headerValue(request *http.Request, name string) string {
+	switch name {
+	case requestTarget:
+		return strings.ToLower(request.Method) + " " + request.URL.RequestURI()
+	case "host":
+		if request.Host != "" {
+			return request.Host
+		}
+		return request.URL.Host
+	case "content-length":
+		if request.ContentLength > 0 {
+			return strconv.FormatInt(request.ContentLength, 10)
+		}
+		return ""
+	}
+	return strings.Join(request.Header.Values(name), ", ")
+}
+
+// requestBody returns the body of the request without consuming it, nil when
+// the request has no body
+func requestBody(request *http.Request) ([]byte, error) {
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// digest returns the Digest header of a body
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parseSignature parses the parameters of a Signature Authorization header
+func parseSignature(authorization string) (map[string]string, error) {
+
+	if !strings.HasPrefix(authorization, "Signature ") {
+		return nil, fmt.Errorf("%w: missing Signature authorization", ErrInvalidSignature)
+	}
+	params := map[string]string{}
+	for _, param := range strings.Split(strings.TrimPrefix(authorization, "Signature "), ",") {
+		i := strings.Index(param, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidSignature, param)
+		}
+		value, err := strconv.Unquote(param[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidSignature, param)
+		}
+		params[strings.TrimSpace(param[:i])] = value
+	}
+	return params, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPrivateKey loads an RSA private key from a PEM file, in PKCS #1 (RSA
+// PRIVATE KEY) or PKCS #8 (PRIVATE KEY) form
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey parses a PEM encoded RSA private key, see LoadPrivateKey
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is a %T, not an RSA key", key)
+		}
+		return rsaKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// LoadPublicKey loads an RSA public key from a PEM file, in PKIX (PUBLIC KEY)
+// or PKCS #1 (RSA PUBLIC KEY) form
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+// ParsePublicKey parses a PEM encoded RSA public key, see LoadPublicKey
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is a %T, not an RSA key", key)
+		}
+		return rsaKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey generates an RSA key for the signature tests
+func testKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+	return key
+}
+
+func TestSigner_shouldBeVerified(t *testing.T) {
+	// prepare
+	restoreInits()
+	key := testKey(t)
+	verifier := &Verifier{Keys: map[string]*rsa.PublicKey{"key-1": &key.PublicKey}}
+
+	var authorization, body string
+	server := newTestServer("/v1/organisation/", func(w http.ResponseWriter, r *http.Request) {
+		verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			data, _ := ioutil.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		})).ServeHTTP(w, r)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithAuthenticator(NewSigner("key-1", key)))
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// test
+	created, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, account.Data.ID, created.ID)
+	assert.Contains(t, body, account.Data.ID)
+	assert.True(t, strings.HasPrefix(authorization, `Signature keyId="key-1",algorithm="rsa-sha256",headers="(request-target) host date digest content-type content-length",signature="`))
+}
+
+func TestSigner_shouldSignEveryAttempt(t *testing.T) {
+	// prepare
+	restoreInits()
+	key := testKey(t)
+	verifier := &Verifier{Keys: map[string]*rsa.PublicKey{"key-1": &key.PublicKey}}
+
+	var dates []string
+	requests := 0
+	server := newTestServer("/v1/organisation/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Nil(t, verifier.Verify(r))
+		dates = append(dates, r.Header.Get("Date"))
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"0673746b-8dd3-4bd2-b398-941bdf2865df"}}`))
+	})
+	defer server.Close()
+	signer := NewSigner("key-1", key)
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	signer.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	verifier.MaxClockSkew = time.Since(now) + time.Hour
+	c, _ := NewClient(server.URL, WithAuthenticator(signer), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, RetryStatusCodes: []int{http.StatusServiceUnavailable}}))
+
+	// test
+	account, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// validate
+	assert.Nil(t, err)
+	assert.NotNil(t, account)
+	assert.EqualValues(t, []string{"Thu, 04 Mar 2021 05:06:08 GMT", "Thu, 04 Mar 2021 05:06:09 GMT"}, dates)
+}
+
+func TestVerifier_shouldRefuseInvalidSignatures(t *testing.T) {
+	// prepare
+	key := testKey(t)
+	verifier := &Verifier{Keys: map[string]*rsa.PublicKey{"key-1": &key.PublicKey}}
+	signed := func() *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+		request.Header.Set("Content-Type", "application/vnd.api+json")
+		assert.Nil(t, NewSigner("key-1", key).Authenticate(request))
+		return request
+	}
+	assert.Nil(t, verifier.Verify(signed()))
+
+	tests := map[string]func(request *http.Request){
+		"unsigned":      func(request *http.Request) { request.Header.Del("Authorization") },
+		"other path":    func(request *http.Request) { request.URL.Path = "/v1/organisation/accounts/42" },
+		"other header":  func(request *http.Request) { request.Header.Set("Content-Type", "text/plain") },
+		"old date":      func(request *http.Request) { request.Header.Set("Date", "Thu, 04 Mar 2021 05:06:08 GMT") },
+		"unknown key":   func(request *http.Request) { verifier.Keys = map[string]*rsa.PublicKey{"key-2": &key.PublicKey} },
+		"other digest":  func(request *http.Request) { request.Header.Set("Digest", digest([]byte("{}"))) },
+		"malformed":     func(request *http.Request) { request.Header.Set("Authorization", "Signature keyId") },
+		"not signature": func(request *http.Request) { request.Header.Set("Authorization", "Bearer token") },
+		"other body": func(request *http.Request) {
+			request.Body = ioutil.NopCloser(strings.NewReader(`{"data":[]}`))
+			request.GetBody = nil
+		},
+	}
+	for name, tamper := range tests {
+		// test
+		verifier.Keys = map[string]*rsa.PublicKey{"key-1": &key.PublicKey}
+		request := signed()
+		tamper(request)
+		err := verifier.Verify(request)
+
+		// validate
+		assert.True(t, errors.Is(err, ErrInvalidSignature), "%s: %v", name, err)
+	}
+}
+
+func TestLoadKeys_shouldParsePEMFiles(t *testing.T) {
+	// prepare
+	key := testKey(t)
+	dir, err := ioutil.TempDir("", "keys")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	files := map[string]*pem.Block{
+		"pkcs1.pem":     {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8.pem":     {Type: "PRIVATE KEY", Bytes: pkcs8},
+		"pkix.pem":      {Type: "PUBLIC KEY", Bytes: pkix},
+		"pkcs1.pub.pem": {Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)},
+		"cert.pem":      {Type: "CERTIFICATE", Bytes: []byte{1}},
+	}
+	for name, block := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600))
+	}
+
+	// test & validate
+	for _, name := range []string{"pkcs1.pem", "pkcs8.pem"} {
+		loaded, err := LoadPrivateKey(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		assert.True(t, key.Equal(loaded), name)
+	}
+	signer, err := NewSignerFromFile("key-1", filepath.Join(dir, "pkcs8.pem"))
+	assert.Nil(t, err)
+	assert.True(t, key.Equal(signer.key))
+
+	for _, name := range []string{"pkix.pem", "pkcs1.pub.pem"} {
+		loaded, err := LoadPublicKey(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		assert.True(t, key.PublicKey.Equal(loaded), name)
+	}
+
+	_, err = LoadPrivateKey(filepath.Join(dir, "cert.pem"))
+	assert.NotNil(t, err)
+	_, err = LoadPublicKey(filepath.Join(dir, "cert.pem"))
+	assert.NotNil(t, err)
+	_, err = LoadPrivateKey(filepath.Join(dir, "missing.pem"))
+	assert.NotNil(t, err)
+	_, err = ParsePublicKey([]byte("not pem"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ForEachAccount calls fn with every account of the form3 api, starting at
+// the page of the options and following the json:api links.next of every
+// page. The response bodies are decoded account by account, so that memory
+// stays flat whatever the number of accounts: only the current account is
+// held, never a whole page.
+//
+// The walk stops at the first error of a page, of ctx or of fn, and that
+// error is returned. Return an error from fn to stop the walk early.
+func (c *Client) ForEachAccount(ctx context.Context, options *ListOptions, fn func(AccountData) error) error {
+
+	path := options.path()
+	for path != "" {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		links, count, err := c.streamPage(ctx, path, fn)
+		if err != nil {
+			return err
+		}
+		if path, err = c.nextPath(links, count, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamPage gets the page of accounts at path and calls fn with every
+// account as soon as it is decoded. It returns the links of the page and the
+// number of accounts on it.
+func (c *Client) streamPage(ctx context.Context, path string, fn func(AccountData) error) (Links, int, error) {
+
+	request, err := c.newRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return Links{}, 0, err
+	}
+	response, err := c.do(OperationList, request)
+	if err != nil {
+		return Links{}, 0, err
+	}
+	defer drainAndClose(response.Body)
+
+	if err := responseError(response); err != nil {
+		return Links{}, 0, err
+	}
+	return decodeAccountStream(json.NewDecoder(response.Body), fn)
+}
+
+// decodeAccountStream walks through the tokens of an AccountList document,
+// decoding the elements of data one at a time. Members other than data and
+// links are skipped.
+func decodeAccountStream(decoder *json.Decoder, fn func(AccountData) error) (Links, int, error) {
+
+	var links Links
+	count := 0
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return links, count, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return links, count, err
+		}
+
+		switch token {
+		case "data":
+			token, err := decoder.Token()
+			if err != nil {
+				return links, count, err
+			}
+			if token == nil {
+				continue
+			}
+			if token != json.Delim('[') {
+				return links, count, fmt.Errorf("list accounts response: data is %v, not an array", token)
+			}
+			for decoder.More() {
+				var account AccountData
+				if err := decoder.Decode(&account); err != nil {
+					return links, count, err
+				}
+				count++
+				if err := fn(account); err != nil {
+					return links, count, err
+				}
+			}
+			if err := expectDelim(decoder, ']'); err != nil {
+				return links, count, err
+			}
+		case "links":
+			if err := decoder.Decode(&links); err != nil {
+				return links, count, err
+			}
+		default:
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return links, count, err
+			}
+		}
+	}
+	return links, count, expectDelim(decoder, '}')
+}
+
+// expectDelim reads the next token and fails unless it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("list accounts response: expected %v, got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachAccount_followsNextLinks(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(2), testAccounts(2), testAccounts(1)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	var ids []string
+	err := c.ForEachAccount(context.Background(), &ListOptions{PageSize: 2}, func(account AccountData) error {
+		ids = append(ids, account.ID)
+		return nil
+	})
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, requests)
+	assert.EqualValues(t, 5, len(ids))
+	assert.EqualValues(t, pages[0][0].ID, ids[0])
+	assert.EqualValues(t, pages[2][0].ID, ids[4])
+}
+
+func TestForEachAccount_whenCallbackFails_shouldStopWithItsError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+	pages := [][]AccountData{testAccounts(3), testAccounts(3)}
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(testPage(r, pages))
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+	stop := errors.New("stop")
+
+	// test
+	count := 0
+	err := c.ForEachAccount(context.Background(), &ListOptions{PageSize: 3}, func(account AccountData) error {
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	// validate
+	assert.Equal(t, stop, err)
+	assert.EqualValues(t, 2, count)
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestForEachAccount_whenPageFails_shouldReturnAPIError(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+
+	// test
+	err := c.ForEachAccount(context.Background(), nil, func(account AccountData) error {
+		t.Fatal("no account expected")
+		return nil
+	})
+
+	// validate
+	assert.True(t, IsNotFound(err))
+}
+
+func TestDecodeAccountStream(t *testing.T) {
+	// links before data, meta skipped
+	body := `{"links":{"next":"/v1/organisation/accounts?page%5Bnumber%5D=1"},"meta":{"count":[1,{"a":2}]},` +
+		`"data":[{"type":"accounts","id":"a"},{"type":"accounts","id":"b"}]}`
+	var ids []string
+	links, count, err := decodeAccountStream(json.NewDecoder(strings.NewReader(body)), func(account AccountData) error {
+		ids = append(ids, account.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.EqualValues(t, []string{"a", "b"}, ids)
+	assert.EqualValues(t, "/v1/organisation/accounts?page%5Bnumber%5D=1", links.Next)
+
+	// null data
+	_, count, err = decodeAccountStream(json.NewDecoder(strings.NewReader(`{"data":null}`)), nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, count)
+
+	// malformed documents
+	for _, body := range []string{`[]`, `{"data":{}}`, `{"data":[{"id":"a"}`, `{"data":[]`} {
+		_, _, err := decodeAccountStream(json.NewDecoder(strings.NewReader(body)), func(AccountData) error { return nil })
+		assert.NotNil(t, err, fmt.Sprintf("body %s", body))
+	}
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTLSReloadInterval is how often the certificate files are checked for
+// changes unless configured otherwise
+const DefaultTLSReloadInterval = time.Minute
+
+// TLSConfig configures the TLS connection to the form3 api, e.g. through a
+// gateway that requires mutual TLS
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM files of the client certificate and its
+	// key, presented to a server that asks for one. Both or none are set.
+	CertFile string
+	KeyFile  string
+	// CAFile is a PEM bundle of the CAs trusted to sign the server
+	// certificate, instead of the CAs of the system. Empty means the system CAs.
+	CAFile string
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS12. 0 means
+	// the default of crypto/tls.
+	MinVersion uint16
+	// ServerName overrides the host name the server certificate is verified
+	// against, when it differs from the host of the base url
+	ServerName string
+	// ReloadInterval is how often the files are checked for changes. New
+	// files are used by the next connection; the files being replaced do not
+	// fail it, since the previous certificates are kept until new ones load.
+	// 0 means DefaultTLSReloadInterval, a negative value disables reloading.
+	ReloadInterval time.Duration
+}
+
+// WithTLSConfig sets the TLS configuration of the connections of the Client.
+// The files are loaded by NewClient, which fails when they cannot be. It
+// configures the transport of the http.Client, which must be an
+// *http.Transport when set with WithHTTPClient.
+func WithTLSConfig(config TLSConfig) Option {
+	return func(c *Client) {
+		c.tlsConfig = &config
+	}
+}
+
+// configureTLS sets up the transport of the http.Client of the Client with
+// its TLSConfig
+func (c *Client) configureTLS() error {
+
+	// the name the server certificate is verified against: with an ip address
+	// in the base url no SNI is sent, so it cannot be taken from the connection
+	serverName := c.tlsConfig.ServerName
+	if serverName == "" {
+		baseURL, err := url.Parse(c.baseURL)
+		if err != nil {
+			return err
+		}
+		serverName = baseURL.Hostname()
+	}
+
+	tlsConfig, err := newTLSConfig(*c.tlsConfig, serverName)
+	if err != nil {
+		return err
+	}
+
+	var transport *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("WithTLSConfig requires an *http.Transport, the http client has a %T", t)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// copy so that a shared http.Client passed with WithHTTPClient is not modified
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
+// newTLSConfig returns the tls.Config of a TLSConfig for a server known as
+// serverName. The certificates are taken from a tlsFiles, which reloads them
+// when the files change.
+func newTLSConfig(config TLSConfig, serverName string) (*tls.Config, error) {
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("tls: the client certificate needs both a cert file and a key file")
+	}
+	if config.ReloadInterval == 0 {
+		config.ReloadInterval = DefaultTLSReloadInterval
+	}
+
+	files := &tlsFiles{config: config, now: time.Now}
+	if err := files.load(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: config.MinVersion,
+		ServerName: config.ServerName,
+	}
+	if config.CertFile != "" {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certificate, _ := files.current()
+			return certificate, nil
+		}
+	}
+	if config.CAFile != "" {
+		// the chain is verified by VerifyConnection instead of crypto/tls, so
+		// that a reloaded CA bundle is used by the next connection
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			_, roots := files.current()
+			return verifyServer(state, roots, serverName)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// verifyServer verifies the certificate chain of the server against roots and
+// serverName, a host name or an ip address
+func verifyServer(state tls.ConnectionState, roots *x509.CertPool, serverName string) error {
+
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("tls: server presented no certificate")
+	}
+	options := x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, certificate := range state.PeerCertificates[1:] {
+		options.Intermediates.AddCert(certificate)
+	}
+	_, err := state.PeerCertificates[0].Verify(options)
+	return err
+}
+
+// tlsFiles holds the client certificate and the CA pool loaded from the files
+// of a TLSConfig, and reloads them when the files change
+type tlsFiles struct {
+	config TLSConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	checked     time.Time
+	modTimes    [3]time.Time
+	certificate *tls.Certificate
+	roots       *x509.CertPool
+}
+
+// current returns the loaded certificate and CA pool, after reloading them
+// when ReloadInterval has passed and the files changed. A failed reload, e.g.
+// of a certificate whose key is not written yet, keeps the previous ones and
+// is tried again at the next check.
+func (f *tlsFiles) current() (*tls.Certificate, *x509.CertPool) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := f.now(); f.config.ReloadInterval > 0 && now.Sub(f.checked) >= f.config.ReloadInterval {
+		f.checked = now
+		if modTimes, err := f.stat(); err == nil && modTimes != f.modTimes {
+			f.loadLocked()
+		}
+	}
+	return f.certificate, f.roots
+}
+
+// load loads the files
+func (f *tlsFiles) load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checked = f.now()
+	return f.loadLocked()
+}
+
+// loadLocked loads the files while f.mu is held, and keeps what was loaded
+// before when one of them fails
+func (f *tlsFiles) loadLocked() error {
+
+	modTimes, err := f.stat()
+	if err != nil {
+		return err
+	}
+
+	var certificate *tls.Certificate
+	if f.config.CertFile != "" {
+		loaded, err := tls.LoadX509KeyPair(f.config.CertFile, f.config.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: loading the client certificate: %v", err)
+		}
+		certificate = &loaded
+	}
+
+	var roots *x509.CertPool
+	if f.config.CAFile != "" {
+		data, err := ioutil.ReadFile(f.config.CAFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls: no certificate found in %s", f.config.CAFile)
+		}
+	}
+
+	f.certificate, f.roots, f.modTimes = certificate, roots, modTimes
+	return nil
+}
+
+// stat returns the modification times of the cert, key and CA files
+func (f *tlsFiles) stat() ([3]time.Time, error) {
+
+	var modTimes [3]time.Time
+	for i, path := range []string{f.config.CertFile, f.config.KeyFile, f.config.CAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCertificate is a certificate and its key, signed by a test CA
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+// newTestCertificate creates a certificate for the common name, signed by
+// parent or self signed when parent is nil. The common name is also the host
+// name, or the ip address, the certificate is valid for.
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate, isCA bool) *testCertificate {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if ip := net.ParseIP(commonName); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else if !isCA {
+		template.DNSNames = []string{commonName}
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return &testCertificate{certificate: certificate, key: key}
+}
+
+// write writes the certificate and its key as PEM files to dir
+func (c *testCertificate) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	key, err := x509.MarshalPKCS8PrivateKey(c.key)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.certificate.Raw}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600))
+	return certFile, keyFile
+}
+
+// tlsCertificate returns the certificate as a tls.Certificate
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.certificate.Raw}, PrivateKey: c.key}
+}
+
+// newMTLSServer starts a server with a certificate for serverName that
+// requires a client certificate signed by ca, and answers with the common
+// name of the client certificate
+func newMTLSServer(t *testing.T, ca *testCertificate, serverName string) *httptest.Server {
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{newTestCertificate(t, serverName, ca, false).tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	return server
+}
+
+func TestWithTLSConfig_shouldPresentTheClientCertificate(t *testing.T) {
+	// prepare
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "Test CA", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCertificate(t, "client-1", ca, false).write(t, dir, "client")
+	server := newMTLSServer(t, ca, "f3.internal")
+	defer server.Close()
+
+	c, err := NewClient(server.URL, WithTLSConfig(TLSConfig{
+		CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "f3.internal", MinVersion: tls.VersionTLS12,
+	}))
+	assert.Nil(t, err)
+
+	// test
+	account, err := c.Fetch(context.Background(), "42")
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "client-1", account.ID)
+}
+
+func TestWithTLSConfig_shouldVerifyTheServer(t *testing.T) {
+	// prepare
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "Test CA", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	otherCAFile, _ := newTestCertificate(t, "Other CA", nil, true).write(t, dir, "other-ca")
+	certFile, keyFile := newTestCertificate(t, "client-1", ca, false).write(t, dir, "client")
+	server := newMTLSServer(t, ca, "f3.internal")
+	defer server.Close()
+
+	tests := map[string]TLSConfig{
+		"other ca":            {CertFile: certFile, KeyFile: keyFile, CAFile: otherCAFile, ServerName: "f3.internal", ReloadInterval: -1},
+		"other server name":   {CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "f4.internal"},
+		"no client cert":      {CAFile: caFile, ServerName: "f3.internal"},
+		"system ca":           {CertFile: certFile, KeyFile: keyFile, ServerName: "f3.internal"},
+		"unsupported version": {CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "f3.internal", MinVersion: tls.VersionTLS13 + 1},
+	}
+	for name, config := range tests {
+		c, err := NewClient(server.URL, WithRetryPolicy(NoRetry), WithTLSConfig(config))
+		assert.Nil(t, err, name)
+
+		// test
+		_, err = c.Fetch(context.Background(), "42")
+
+		// validate
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestWithTLSConfig_shouldVerifyTheIPAddressOfTheBaseURL(t *testing.T) {
+	// prepare
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "Test CA", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCertificate(t, "client-1", ca, false).write(t, dir, "client")
+	config := TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}
+
+	tests := map[string]struct {
+		serverName string
+		valid      bool
+	}{
+		"certificate for the ip address": {serverName: "127.0.0.1", valid: true},
+		"certificate for a host name":    {serverName: "f3.internal", valid: false},
+		"certificate for another ip":     {serverName: "10.0.0.1", valid: false},
+	}
+	for name, test := range tests {
+		server := newMTLSServer(t, ca, test.serverName)
+		c, err := NewClient(server.URL, WithRetryPolicy(NoRetry), WithTLSConfig(config))
+		assert.Nil(t, err, name)
+
+		// test
+		_, err = c.Fetch(context.Background(), "42")
+
+		// validate
+		assert.EqualValues(t, test.valid, err == nil, name)
+		server.Close()
+	}
+}
+
+func TestWithTLSConfig_shouldReloadChangedFiles(t *testing.T) {
+	// prepare
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "Test CA", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCertificate(t, "client-1", ca, false).write(t, dir, "client")
+	server := newMTLSServer(t, ca, "f3.internal")
+	defer server.Close()
+
+	c, err := NewClient(server.URL, WithTLSConfig(TLSConfig{
+		CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "f3.internal", ReloadInterval: time.Nanosecond,
+	}))
+	assert.Nil(t, err)
+	account, err := c.Fetch(context.Background(), "42")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "client-1", account.ID)
+
+	// test
+	newTestCertificate(t, "client-2", ca, false).write(t, dir, "client")
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{certFile, keyFile} {
+		assert.Nil(t, os.Chtimes(path, later, later))
+	}
+	c.httpClient.CloseIdleConnections()
+	account, err = c.Fetch(context.Background(), "42")
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "client-2", account.ID)
+
+	// test: a broken file keeps the previous certificate
+	assert.Nil(t, ioutil.WriteFile(keyFile, []byte("not a key"), 0600))
+	c.httpClient.CloseIdleConnections()
+	account, err = c.Fetch(context.Background(), "42")
+
+	// validate
+	assert.Nil(t, err)
+	assert.EqualValues(t, "client-2", account.ID)
+}
+
+func TestWithTLSConfig_errors(t *testing.T) {
+	// prepare
+	dir, err := ioutil.TempDir("", "tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := newTestCertificate(t, "client-1", nil, false).write(t, dir, "client")
+
+	tests := map[string][]Option{
+		"missing key file":  {WithTLSConfig(TLSConfig{CertFile: certFile})},
+		"missing file":      {WithTLSConfig(TLSConfig{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")})},
+		"not a ca bundle":   {WithTLSConfig(TLSConfig{CAFile: keyFile})},
+		"custom transport":  {WithHTTPClient(&http.Client{Transport: RoundTripperFunc(nil)}), WithTLSConfig(TLSConfig{})},
+		"mismatched key":    {WithTLSConfig(TLSConfig{CertFile: certFile, KeyFile: newTestKeyFile(t, dir)})},
+		"missing ca bundle": {WithTLSConfig(TLSConfig{CAFile: filepath.Join(dir, "missing.crt")})},
+	}
+	for name, options := range tests {
+		// test
+		c, err := NewClient("https://localhost:8080", options...)
+
+		// validate
+		assert.Nil(t, c, name)
+		assert.NotNil(t, err, name)
+	}
+
+	// a shared http.Client is not modified
+	shared := &http.Client{}
+	c, err := NewClient("https://localhost:8080", WithHTTPClient(shared), WithTLSConfig(TLSConfig{ServerName: "f3.internal"}))
+	assert.Nil(t, err)
+	assert.Nil(t, shared.Transport)
+	assert.EqualValues(t, "f3.internal", c.httpClient.Transport.(*http.Transport).TLSClientConfig.ServerName)
+}
+
+// newTestKeyFile writes the key of a new certificate to dir
+func newTestKeyFile(t *testing.T, dir string) string {
+	_, keyFile := newTestCertificate(t, "other", nil, false).write(t, dir, "other")
+	return keyFile
+}
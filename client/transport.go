@@ -0,0 +1,97 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the connections of a Client to the form3 api
+type TransportConfig struct {
+	// MaxIdleConns caps the idle connections kept open to all hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps the idle connections kept open to one host. It
+	// should be as large as the number of concurrent requests, since a
+	// connection that cannot be kept idle is closed after its request.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps all connections to one host, 0 means no cap
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of the TCP keep-alive probes, a negative
+	// value disables them
+	KeepAlive time.Duration
+	// DialTimeout caps the time to open a connection
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout caps the time of the TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout caps the wait for the response headers after the
+	// request was written, 0 means no cap
+	ResponseHeaderTimeout time.Duration
+	// DisableHTTP2 keeps the connections on HTTP/1.1, which is otherwise
+	// upgraded to HTTP/2 when the server supports it over TLS
+	DisableHTTP2 bool
+}
+
+// DefaultTransportConfig returns the TransportConfig of a Client created with
+// NewClient
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+		KeepAlive:           30 * time.Second,
+		DialTimeout:         10 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// sharedTransport is the transport of the Clients that are created without
+// WithHTTPClient or WithTransportConfig, so that they share one connection
+// pool
+var sharedTransport = NewTransport(DefaultTransportConfig())
+
+// NewTransport creates an *http.Transport tuned with the config, e.g. to be
+// shared by several http.Clients
+func NewTransport(config TransportConfig) *http.Transport {
+
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     !config.DisableHTTP2,
+	}
+	if config.DisableHTTP2 {
+		// a non nil empty map turns the HTTP/2 upgrade off
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// WithTransportConfig gives the Client its own transport, tuned with the
+// config, instead of the transport shared by the Clients. It replaces the
+// transport of an http.Client set with WithHTTPClient.
+func WithTransportConfig(config TransportConfig) Option {
+	return func(c *Client) {
+		c.transportConfig = &config
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the transport of the
+// Client, which is shared with the other Clients unless it was set with
+// WithHTTPClient or WithTransportConfig. The connections in use are not
+// interrupted.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
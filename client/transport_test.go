@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newConnCountingServer starts a server that answers every request with an
+// account followed by trailing new lines, and counts the new connections
+func newConnCountingServer(trailing int) (*httptest.Server, *int32) {
+	var connections int32
+	body := []byte(`{"data":{"id":"0673746b-8dd3-4bd2-b398-941bdf2865df"}}` + strings.Repeat("\n", trailing))
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	return server, &connections
+}
+
+func TestNewClient_shouldReuseConnections(t *testing.T) {
+	// prepare
+	restoreInits()
+	server, connections := newConnCountingServer(3)
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithTransportConfig(DefaultTransportConfig()))
+	defer c.CloseIdleConnections()
+
+	// test
+	for i := 0; i < 50; i++ {
+		_, err := c.Fetch(context.Background(), "0673746b-8dd3-4bd2-b398-941bdf2865df")
+		assert.Nil(t, err)
+	}
+
+	// validate
+	assert.EqualValues(t, 1, atomic.LoadInt32(connections))
+}
+
+func TestUnmarshallGetAccountResponse_whenReadFails_shouldReuseTheConnection(t *testing.T) {
+	// prepare
+	restoreInits()
+	defer restoreInits()
+	server, connections := newConnCountingServer(32 << 10)
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithTransportConfig(DefaultTransportConfig()))
+	defer c.CloseIdleConnections()
+	IOResponseBodyReader = func(r io.Reader) ([]byte, error) {
+		return nil, errors.New("IOResponseBodyReader faillure")
+	}
+
+	// test
+	for i := 0; i < 5; i++ {
+		response, err := c.GetAccount("0673746b-8dd3-4bd2-b398-941bdf2865df")
+		assert.Nil(t, err)
+		_, err = UnmarshallGetAccountResponse(response)
+		assert.NotNil(t, err)
+	}
+
+	// validate
+	assert.EqualValues(t, 1, atomic.LoadInt32(connections))
+}
+
+func TestNewClient_shouldShareTheTransport(t *testing.T) {
+	// test
+	first, _ := NewClient("http://localhost:8080")
+	second, _ := NewClient("http://localhost:8080", WithTimeout(time.Second))
+	own, _ := NewClient("http://localhost:8080", WithTransportConfig(TransportConfig{MaxIdleConnsPerHost: 4, DisableHTTP2: true}))
+	shared := &http.Client{}
+	custom, _ := NewClient("http://localhost:8080", WithHTTPClient(shared), WithTransportConfig(DefaultTransportConfig()))
+
+	// validate
+	assert.True(t, first.httpClient.Transport == sharedTransport)
+	assert.True(t, second.httpClient.Transport == sharedTransport)
+	assert.True(t, hostClient("http://localhost:8080").httpClient.Transport == sharedTransport)
+
+	transport := own.httpClient.Transport.(*http.Transport)
+	assert.True(t, transport != sharedTransport)
+	assert.EqualValues(t, 4, transport.MaxIdleConnsPerHost)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+
+	assert.Nil(t, shared.Transport)
+	assert.EqualValues(t, 32, custom.httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+// benchmarkGetAccount sends a thousand sequential GetAccount requests per
+// iteration with a Client using the transport
+func benchmarkGetAccount(b *testing.B, transport *http.Transport) {
+	restoreInits()
+	server, connections := newConnCountingServer(3)
+	defer server.Close()
+	c, _ := NewClient(server.URL, WithHTTPClient(&http.Client{Transport: transport}))
+	defer c.CloseIdleConnections()
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < 1000; i++ {
+			response, err := c.GetAccountWithContext(ctx, "0673746b-8dd3-4bd2-b398-941bdf2865df")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := decodeResponse(response, &Account{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(atomic.LoadInt32(connections))/float64(b.N), "conns/op")
+}
+
+// BenchmarkGetAccount_sharedTransport reuses the connections of the pool
+func BenchmarkGetAccount_sharedTransport(b *testing.B) {
+	benchmarkGetAccount(b, NewTransport(DefaultTransportConfig()))
+}
+
+// BenchmarkGetAccount_newConnectionPerCall opens a connection for every call,
+// as when the bodies are not drained and closed
+func BenchmarkGetAccount_newConnectionPerCall(b *testing.B) {
+	transport := NewTransport(DefaultTransportConfig())
+	transport.DisableKeepAlives = true
+	benchmarkGetAccount(b, transport)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// AccountPatch holds the attributes changed by UpdateAccount. Only the
+// attributes that are set or null are sent: a null attribute is cleared and
+// every absent one keeps its value.
+type AccountPatch struct {
+	Country                 OptionalString
+	BaseCurrency            OptionalString
+	BankID                  OptionalString
+	BankIDCode              OptionalString
+	Bic                     OptionalString
+	AccountNumber           OptionalString
+	Iban                    OptionalString
+	Name                    OptionalStrings
+	AlternativeNames        OptionalStrings
+	AccountClassification   OptionalString
+	JointAccount            OptionalBool
+	AccountMatchingOptOut   OptionalBool
+	SecondaryIdentification OptionalString
+	Switched                OptionalBool
+	Status                  OptionalString
+}
+
+// MarshalJSON implements json.Marshaler, leaving out the absent attributes
+func (p AccountPatch) MarshalJSON() ([]byte, error) {
+	o := &jsonObject{}
+	o.add("country", p.Country)
+	o.add("base_currency", p.BaseCurrency)
+	o.add("bank_id", p.BankID)
+	o.add("bank_id_code", p.BankIDCode)
+	o.add("bic", p.Bic)
+	o.add("account_number", p.AccountNumber)
+	o.add("iban", p.Iban)
+	o.add("name", p.Name)
+	o.add("alternative_names", p.AlternativeNames)
+	o.add("account_classification", p.AccountClassification)
+	o.add("joint_account", p.JointAccount)
+	o.add("account_matching_opt_out", p.AccountMatchingOptOut)
+	o.add("secondary_identification", p.SecondaryIdentification)
+	o.add("switched", p.Switched)
+	o.add("status", p.Status)
+	return o.MarshalJSON()
+}
+
+// accountPatch is the json:api document of a PATCH request. The version is
+// always sent, as 0 is the version of a new account.
+type accountPatch struct {
+	Data struct {
+		Type       string        `json:"type"`
+		ID         string        `json:"id"`
+		Version    int           `json:"version"`
+		Attributes *AccountPatch `json:"attributes"`
+	} `json:"data"`
+}
+
+// UpdateAccount changes the attributes of the patch on the account with the
+// specified accountID and returns the updated account. The version is the
+// version the caller last saw: when the account was changed in the meantime
+// the form3 api refuses the update and a *VersionConflictError is returned
+// (see IsVersionConflict).
+func (c *Client) UpdateAccount(ctx context.Context, accountID string, version int, patch *AccountPatch) (*AccountData, error) {
+
+	document := &accountPatch{}
+	document.Data.Type = "accounts"
+	document.Data.ID = accountID
+	document.Data.Version = version
+	document.Data.Attributes = patch
+	if document.Data.Attributes == nil {
+		document.Data.Attributes = &AccountPatch{}
+	}
+
+	jsonBytes, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := c.newRequestWithContext(ctx, http.MethodPatch, accountsPath+"/"+accountID, bytes.NewReader(jsonBytes))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/vnd.api+json")
+
+	response, err := c.do(OperationUpdate, request)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := &Account{}
+	if err := decodeResponse(response, updated); err != nil {
+		return nil, versionConflict(err)
+	}
+	return &updated.Data, nil
+}
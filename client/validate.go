@@ -0,0 +1,279 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	guuid "github.com/google/uuid"
+
+	"github.com/eefth/f3-assignment/client/iban"
+	"github.com/eefth/f3-assignment/client/modulus"
+)
+
+// The limits of the name attributes of the form3 api
+const (
+	maxNameLines            = 4
+	maxAlternativeNameLines = 3
+	maxNameLength           = 140
+)
+
+// FieldError is a violation of a field of an account, found by Validate
+type FieldError struct {
+	// Field is the json path of the field, e.g. attributes.name[1]
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors lists all the violations found by Validate
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Error()
+	}
+	return "invalid account: " + strings.Join(messages, "; ")
+}
+
+// AccountValidator is an additional check of an account, e.g. against data
+// loaded at runtime. The AccountValidators of a Client, set with
+// WithAccountValidators, are run by Validate before every create.
+type AccountValidator interface {
+	// ValidateAccount returns the violations of the account, none when it is valid
+	ValidateAccount(account *AccountData) []FieldError
+}
+
+// WithAccountValidators adds AccountValidators that Create and CreateOrGet run
+// with Validate before sending an account
+func WithAccountValidators(validators ...AccountValidator) Option {
+	return func(c *Client) {
+		c.validators = append(c.validators, validators...)
+	}
+}
+
+// countryRule holds the bank attributes the form3 api requires or forbids for
+// accounts of a country, and the currency of the country
+type countryRule struct {
+	currency        string
+	bankIDCode      string
+	bankIDRequired  bool
+	bankIDForbidden bool
+	bankID          *regexp.Regexp
+	bicRequired     bool
+}
+
+// countryRules are the rules of the countries supported by the form3 api
+var countryRules = map[string]countryRule{
+	"AU": {currency: "AUD", bankIDCode: "AUBSB", bankID: regexp.MustCompile(`^[0-9]{6}$`), bicRequired: true},
+	"BE": {currency: "EUR", bankIDCode: "BE", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{3}$`)},
+	"CA": {currency: "CAD", bankIDCode: "CACPA", bankID: regexp.MustCompile(`^0[0-9]{8}$`), bicRequired: true},
+	"CH": {currency: "CHF", bankIDCode: "CHBCC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{5}$`)},
+	"DE": {currency: "EUR", bankIDCode: "DEBLZ", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{8}$`)},
+	"ES": {currency: "EUR", bankIDCode: "ESNCC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{8,9}$`)},
+	"FR": {currency: "EUR", bankIDCode: "FR", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9A-Z]{10}$`)},
+	"GB": {currency: "GBP", bankIDCode: "GBDSC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{6}$`), bicRequired: true},
+	"GR": {currency: "EUR", bankIDCode: "GRBIC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{7}$`)},
+	"HK": {currency: "HKD", bankIDCode: "HKNCC", bankID: regexp.MustCompile(`^[0-9]{3}$`), bicRequired: true},
+	"IT": {currency: "EUR", bankIDCode: "ITNCC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{10,11}$`)},
+	"LU": {currency: "EUR", bankIDCode: "LULUX", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{3}$`)},
+	"NL": {currency: "EUR", bankIDForbidden: true, bicRequired: true},
+	"PL": {currency: "PLN", bankIDCode: "PLKNR", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{8}$`)},
+	"PT": {currency: "EUR", bankIDCode: "PTNCC", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{8}$`)},
+	"US": {currency: "USD", bankIDCode: "USABA", bankIDRequired: true, bankID: regexp.MustCompile(`^[0-9]{9}$`), bicRequired: true},
+}
+
+// bicPattern is the format of a BIC: bank code, country code, location code
+// and an optional branch code
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// accountClassifications are the values of attributes.account_classification
+var accountClassifications = map[string]bool{"Personal": true, "Business": true}
+
+// Validate checks the account before it is sent to the form3 api: the ids,
+// the required attributes of its country, the ISO 3166 country code, the ISO
+// 4217 currency code, the BIC format, the bank id code of the country, the
+// iban and its match with the country and the bank id, the name lines and the
+// account classification, followed by the given validators. It returns all
+// violations at once as ValidationErrors, nil when the account is valid.
+func (a *AccountData) Validate(validators ...AccountValidator) error {
+
+	v := &validator{}
+
+	if a.Type != "accounts" {
+		v.add("type", "must be accounts, got %q", a.Type)
+	}
+	if _, err := guuid.Parse(a.ID); err != nil {
+		v.add("id", "must be a uuid, got %q", a.ID)
+	}
+	if _, err := guuid.Parse(a.OrganisationID); err != nil {
+		v.add("organisation_id", "must be a uuid, got %q", a.OrganisationID)
+	}
+
+	a.Attributes.validate(v)
+	for _, validator := range validators {
+		v.errors = append(v.errors, validator.ValidateAccount(a)...)
+	}
+
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// validate adds the violations of the attributes to v
+func (a *AccountAttributes) validate(v *validator) {
+
+	switch {
+	case a.Country == "":
+		v.add("attributes.country", "is required")
+	case !countryCodes[a.Country]:
+		v.add("attributes.country", "must be an ISO 3166 country code, got %q", a.Country)
+	}
+
+	if a.BaseCurrency.IsSet() && !currencyCodes[a.BaseCurrency.Value()] {
+		v.add("attributes.base_currency", "must be an ISO 4217 currency code, got %q", a.BaseCurrency.Value())
+	}
+
+	if a.Bic.IsSet() && !bicPattern.MatchString(a.Bic.Value()) {
+		v.add("attributes.bic", "must be 8 or 11 upper case letters and digits, got %q", a.Bic.Value())
+	}
+
+	if rule, ok := countryRules[a.Country]; ok {
+		a.validateCountryRule(v, rule)
+	} else if a.BankIDCode.IsSet() {
+		v.add("attributes.bank_id_code", "is not supported for country %q", a.Country)
+	}
+
+	if a.Iban.IsSet() {
+		a.validateIban(v)
+	}
+
+	v.lines("attributes.name", a.Name, maxNameLines)
+	if len(a.Name) == 0 {
+		v.add("attributes.name", "is required")
+	}
+	v.lines("attributes.alternative_names", a.AlternativeNames.Value(), maxAlternativeNameLines)
+
+	if a.AccountClassification.IsSet() && !accountClassifications[a.AccountClassification.Value()] {
+		v.add("attributes.account_classification", "must be Personal or Business, got %q", a.AccountClassification.Value())
+	}
+	if len(a.SecondaryIdentification.Value()) > maxNameLength {
+		v.add("attributes.secondary_identification", "must be at most %d characters", maxNameLength)
+	}
+}
+
+// validateCountryRule adds the violations of the bank attributes of a
+// country with a countryRule to v
+func (a *AccountAttributes) validateCountryRule(v *validator, rule countryRule) {
+
+	switch {
+	case rule.bankIDCode == "" && a.BankIDCode.IsSet() && a.BankIDCode.Value() != "":
+		v.add("attributes.bank_id_code", "must be empty for country %s", a.Country)
+	case rule.bankIDCode != "" && !a.BankIDCode.IsSet():
+		v.add("attributes.bank_id_code", "is required for country %s", a.Country)
+	case rule.bankIDCode != "" && a.BankIDCode.Value() != rule.bankIDCode:
+		v.add("attributes.bank_id_code", "must be %s for country %s, got %q", rule.bankIDCode, a.Country, a.BankIDCode.Value())
+	}
+
+	switch {
+	case rule.bankIDForbidden && a.BankID.IsSet() && a.BankID.Value() != "":
+		v.add("attributes.bank_id", "is not supported for country %s", a.Country)
+	case !a.BankID.IsSet():
+		if rule.bankIDRequired {
+			v.add("attributes.bank_id", "is required for country %s", a.Country)
+		}
+	case rule.bankID != nil && !rule.bankID.MatchString(a.BankID.Value()):
+		v.add("attributes.bank_id", "has not the format of country %s, got %q", a.Country, a.BankID.Value())
+	}
+
+	if rule.bicRequired && !a.Bic.IsSet() {
+		v.add("attributes.bic", "is required for country %s", a.Country)
+	}
+}
+
+// validateIban adds the violations of the iban to v: an invalid iban, an
+// iban of another country or with another bank id than the account
+func (a *AccountAttributes) validateIban(v *validator) {
+
+	value := a.Iban.Value()
+	if err := iban.Validate(value); err != nil {
+		v.add("attributes.iban", "%v", err)
+		return
+	}
+	if country := iban.Country(value); country != a.Country {
+		v.add("attributes.iban", "is an iban of country %s, not of %s", country, a.Country)
+		return
+	}
+	if bankID, ok := iban.BankID(value); ok && a.BankID.IsSet() && bankID != a.BankID.Value() {
+		v.add("attributes.iban", "has bank id %s, which does not match attributes.bank_id %s", bankID, a.BankID.Value())
+	}
+}
+
+// modulusValidator is the AccountValidator of NewModulusValidator
+type modulusValidator struct {
+	table *modulus.Table
+}
+
+// NewModulusValidator returns an AccountValidator that runs the VocaLink
+// modulus check of the modulus package on the account number of GB accounts
+// with a sort code (bank id code GBDSC), so that an account that payments
+// could not be routed to is not created. Accounts without an account number
+// are not checked, since the form3 api generates one.
+func NewModulusValidator(table *modulus.Table) AccountValidator {
+	return &modulusValidator{table: table}
+}
+
+// ValidateAccount implements AccountValidator
+func (m *modulusValidator) ValidateAccount(account *AccountData) []FieldError {
+
+	a := account.Attributes
+	if a.Country != "GB" || a.BankIDCode.Value() != "GBDSC" || !a.AccountNumber.IsSet() {
+		return nil
+	}
+	// a malformed sort code is reported by the country rule of GB
+	if !countryRules["GB"].bankID.MatchString(a.BankID.Value()) {
+		return nil
+	}
+
+	err := m.table.Check(a.BankID.Value(), a.AccountNumber.Value())
+	switch {
+	case errors.Is(err, modulus.ErrInvalidFormat):
+		return []FieldError{{Field: "attributes.account_number", Message: fmt.Sprintf("must be 6 to 8 digits for country GB, got %q", a.AccountNumber.Value())}}
+	case err != nil:
+		return []FieldError{{Field: "attributes.account_number", Message: fmt.Sprintf("fails the modulus check of sort code %s", a.BankID.Value())}}
+	}
+	return nil
+}
+
+// validator collects the violations of Validate
+type validator struct {
+	errors ValidationErrors
+}
+
+// add adds a violation of field
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// lines adds the violations of a name attribute made of at most maxLines
+// non empty lines of up to maxNameLength characters
+func (v *validator) lines(field string, lines []string, maxLines int) {
+
+	if len(lines) > maxLines {
+		v.add(field, "must have at most %d lines, got %d", maxLines, len(lines))
+	}
+	for i, line := range lines {
+		lineField := fmt.Sprintf("%s[%d]", field, i)
+		if strings.TrimSpace(line) == "" {
+			v.add(lineField, "must not be empty")
+		} else if len([]rune(line)) > maxNameLength {
+			v.add(lineField, "must be at most %d characters", maxNameLength)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eefth/f3-assignment/client/modulus"
+)
+
+func TestValidate_validAccount(t *testing.T) {
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+
+	assert.Nil(t, account.Data.Validate())
+}
+
+func TestValidate_shouldReturnAllViolations(t *testing.T) {
+	// prepare
+	account := &AccountData{
+		Type:           "account",
+		ID:             "42",
+		OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df",
+		Attributes: AccountAttributes{
+			Country:               "XX",
+			BaseCurrency:          String("GBX"),
+			BankIDCode:            String("GBDSC"),
+			Bic:                   String("NWBK22"),
+			Name:                  []string{"Samantha Holder", " ", strings.Repeat("a", 141), "d", "e"},
+			AccountClassification: String("Private"),
+		},
+	}
+
+	// test
+	err := account.Validate()
+
+	// validate
+	var validationErrors ValidationErrors
+	assert.True(t, errors.As(err, &validationErrors))
+	assert.True(t, IsValidationError(err))
+	fields := make([]string, len(validationErrors))
+	for i, fieldError := range validationErrors {
+		fields[i] = fieldError.Field
+	}
+	assert.EqualValues(t, []string{
+		"type",
+		"id",
+		"attributes.country",
+		"attributes.base_currency",
+		"attributes.bic",
+		"attributes.bank_id_code",
+		"attributes.name",
+		"attributes.name[1]",
+		"attributes.name[2]",
+		"attributes.account_classification",
+	}, fields)
+	assert.Contains(t, err.Error(), `attributes.country: must be an ISO 3166 country code, got "XX"`)
+}
+
+func TestValidate_countryRules(t *testing.T) {
+	// prepare
+	valid := func(country string) *AccountData {
+		return &AccountData{Type: "accounts", ID: "0673746b-8dd3-4bd2-b398-941bdf2865df",
+			OrganisationID: "9864746b-8dd3-4bd2-b398-941bdf2865df",
+			Attributes:     AccountAttributes{Country: country, Name: []string{"Samantha Holder"}}}
+	}
+
+	germany := valid("DE")
+	germany.Attributes.BankID = String("37040044")
+	germany.Attributes.BankIDCode = String("DEBLZ")
+	assert.Nil(t, germany.Validate())
+
+	germany.Attributes.BankID = String("3704")
+	germany.Attributes.BankIDCode = String("GBDSC")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.bank_id_code", Message: `must be DEBLZ for country DE, got "GBDSC"`},
+		{Field: "attributes.bank_id", Message: `has not the format of country DE, got "3704"`},
+	}, germany.Validate())
+
+	uk := valid("GB")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.bank_id_code", Message: "is required for country GB"},
+		{Field: "attributes.bank_id", Message: "is required for country GB"},
+		{Field: "attributes.bic", Message: "is required for country GB"},
+	}, uk.Validate())
+
+	netherlands := valid("NL")
+	netherlands.Attributes.Bic = String("ABNANL2A")
+	assert.Nil(t, netherlands.Validate())
+
+	// a country without rules only gets the generic checks
+	assert.Nil(t, valid("JP").Validate())
+}
+
+func TestValidate_iban(t *testing.T) {
+	// prepare
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// test & validate
+	account.Data.Attributes.BankID = String("601613")
+	account.Data.Attributes.Iban = String("GB29 NWBK 6016 1331 9268 19")
+	assert.Nil(t, account.Data.Validate())
+
+	account.Data.Attributes.Iban = String("GB28NWBK60161331926819")
+	assert.EqualValues(t, "attributes.iban", account.Data.Validate().(ValidationErrors)[0].Field)
+
+	account.Data.Attributes.Iban = String("DE89370400440532013000")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.iban", Message: "is an iban of country DE, not of GB"},
+	}, account.Data.Validate())
+
+	account.Data.Attributes.BankID = String("400300")
+	account.Data.Attributes.Iban = String("GB29NWBK60161331926819")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.iban", Message: "has bank id 601613, which does not match attributes.bank_id 400300"},
+	}, account.Data.Validate())
+}
+
+func TestCreate_whenAccountIsInvalid_shouldNotSendIt(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	c, _ := NewClient(server.URL)
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+	account.Data.Attributes.Bic = String("nwbkgb22")
+
+	// test
+	created, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, created)
+	assert.True(t, IsValidationError(err))
+	assert.EqualValues(t, 0, requests)
+}
+
+func TestValidate_modulusValidator(t *testing.T) {
+	// prepare
+	table, err := modulus.LoadTable(strings.NewReader("400000 409999 MOD10 0 0 0 0 0 0 0 0 0 0 0 0 0 1"))
+	assert.Nil(t, err)
+	validator := NewModulusValidator(table)
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+
+	// test & validate
+	assert.Nil(t, account.Data.Validate(validator))
+
+	account.Data.Attributes.AccountNumber = String("41426810")
+	assert.Nil(t, account.Data.Validate(validator))
+
+	account.Data.Attributes.AccountNumber = String("41426819")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.account_number", Message: "fails the modulus check of sort code 400300"},
+	}, account.Data.Validate(validator))
+
+	account.Data.Attributes.AccountNumber = String("4142")
+	assert.EqualValues(t, ValidationErrors{
+		{Field: "attributes.account_number", Message: `must be 6 to 8 digits for country GB, got "4142"`},
+	}, account.Data.Validate(validator))
+
+	// other countries are not checked
+	account.Data.Attributes.Country = "AU"
+	account.Data.Attributes.BankIDCode = String("AUBSB")
+	assert.Nil(t, account.Data.Validate(validator))
+}
+
+func TestCreate_shouldRunTheAccountValidatorsOfTheClient(t *testing.T) {
+	// prepare
+	restoreInits()
+	uri := "/v1/organisation/accounts"
+
+	requests := 0
+	server := newTestServer(uri, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	table, _ := modulus.LoadTable(strings.NewReader("400000 409999 MOD10 0 0 0 0 0 0 0 0 0 0 0 0 0 1"))
+	c, _ := NewClient(server.URL, WithAccountValidators(NewModulusValidator(table)))
+	account := CreateRequestBody("0673746b-8dd3-4bd2-b398-941bdf2865df", "9864746b-8dd3-4bd2-b398-941bdf2865df")
+	account.Data.Attributes.AccountNumber = String("41426819")
+
+	// test
+	created, err := c.Create(context.Background(), &account.Data)
+
+	// validate
+	assert.Nil(t, created)
+	assert.True(t, IsValidationError(err))
+	assert.Contains(t, err.Error(), "attributes.account_number: fails the modulus check")
+	assert.EqualValues(t, 0, requests)
+}